@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	db "github.com/theHousedev/pay-log/backend/database"
+)
+
+// setupAircraft lists the aircraft registry (GET, optional ?active=),
+// registers an aircraft (POST) or edits one (PUT).
+func setupAircraft(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			var active *bool
+			if raw := r.URL.Query().Get("active"); raw != "" && raw != "all" {
+				value, err := strconv.ParseBool(raw)
+				if err != nil {
+					toJSON(w, db.Response{
+						Status:  "ERROR",
+						Message: fmt.Sprintf("Invalid active filter '%s'", raw),
+					})
+					return
+				}
+				active = &value
+			}
+
+			fleet, err := database.GetAircraft(active)
+			if err != nil {
+				toJSON(w, db.Response{
+					Status:  "ERROR",
+					Message: fmt.Sprintf("Failed to get aircraft: %v", err),
+				})
+				return
+			}
+
+			data, _ := json.Marshal(fleet)
+			toJSON(w, db.Response{
+				Status:  "OK",
+				Message: "Aircraft retrieved",
+				Data:    data,
+			})
+
+		case http.MethodPost, http.MethodPut:
+			aircraft := db.Aircraft{Active: true}
+			if err := json.NewDecoder(r.Body).Decode(&aircraft); err != nil {
+				toJSON(w, db.Response{
+					Status:  "ERROR",
+					Message: "Invalid JSON format",
+				})
+				return
+			}
+
+			if r.Method == http.MethodPost {
+				toJSON(w, database.CreateAircraft(aircraft))
+			} else {
+				toJSON(w, database.UpdateAircraft(aircraft))
+			}
+
+		default:
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// setupAircraftHours reports hours flown per aircraft for a view (the
+// current pay period by default) alongside all-time totals. ?tail= limits
+// both to one aircraft.
+func setupAircraftHours(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		_, beginDate, endDate, err := requestRange(database, r)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: err.Error(),
+			})
+			return
+		}
+		tail := strings.TrimSpace(r.URL.Query().Get("tail"))
+
+		inView, err := database.GetAircraftHours(beginDate, endDate, tail)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to get aircraft hours: %v", err),
+			})
+			return
+		}
+		overall, err := database.GetAircraftHours("all", "all", tail)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to get aircraft hours: %v", err),
+			})
+			return
+		}
+
+		data, _ := json.Marshal(map[string]interface{}{
+			"begin_date": beginDate,
+			"end_date":   endDate,
+			"aircraft":   inView,
+			"overall":    overall,
+		})
+		toJSON(w, db.Response{
+			Status:  "OK",
+			Message: fmt.Sprintf("Aircraft hours from %s to %s", beginDate, endDate),
+			Data:    data,
+		})
+	}
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	db "github.com/theHousedev/pay-log/backend/database"
+	"github.com/theHousedev/pay-log/backend/ics"
+)
+
+// feedUIDDomain marks events published by our own feed so importing the
+// feed back in does not duplicate entries.
+const feedUIDDomain = "@pay-log"
+
+// maxCalendarUpload caps an imported .ics file.
+const maxCalendarUpload = 5 << 20
+
+var typeLabels = map[string]string{
+	"flight": "Flight",
+	"ground": "Ground",
+	"sim":    "Sim",
+	"admin":  "Admin",
+	"misc":   "Misc",
+}
+
+// setupCalendarFeed publishes logged entries and open scheduled lessons as
+// an iCalendar feed, optionally limited with from/to (YYYY-MM-DD).
+func setupCalendarFeed(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		from := r.URL.Query().Get("from")
+		to := r.URL.Query().Get("to")
+		for _, date := range []string{from, to} {
+			if _, err := time.Parse("2006-01-02", date); date != "" && err != nil {
+				http.Error(w, fmt.Sprintf("Invalid date '%s', expected YYYY-MM-DD", date), http.StatusBadRequest)
+				return
+			}
+		}
+		entries, _, err := database.FetchFilteredEntries(db.EntryFilter{BeginDate: from, EndDate: to})
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to get entries: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		if from == "" {
+			from = "0001-01-01"
+		}
+		if to == "" {
+			to = "9999-12-31"
+		}
+		lessons, err := database.GetLessons(from, to, "scheduled")
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to get lessons: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		events := make([]ics.Event, 0, len(entries)+len(lessons))
+		for _, entry := range entries {
+			events = append(events, entryEvent(entry))
+		}
+		for _, lesson := range lessons {
+			events = append(events, lessonEvent(lesson))
+		}
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Disposition", `inline; filename="pay-log.ics"`)
+		ics.Write(w, "Pay Log", events)
+	}
+}
+
+// eventTimes places an event at date and "HH:MM" for the given hours, or
+// makes it all-day when there is no time or no length.
+func eventTimes(event *ics.Event, date, clock string, hours float64) {
+	day, _ := time.ParseInLocation("2006-01-02", strings.Split(date, "T")[0], time.Local)
+	start, err := time.ParseInLocation("2006-01-02 15:04", day.Format("2006-01-02")+" "+clock, time.Local)
+	if err != nil || hours <= 0 {
+		event.Start, event.End, event.AllDay = day, day.AddDate(0, 0, 1), true
+		return
+	}
+	event.Start = start
+	event.End = start.Add(time.Duration(hours * float64(time.Hour)))
+}
+
+func entryEvent(entry db.Entry) ics.Event {
+	hours := 0.0
+	for _, h := range []*float64{entry.FlightHours, entry.GroundHours, entry.SimHours, entry.AdminHours} {
+		if h != nil {
+			hours += *h
+		}
+	}
+
+	label := typeLabels[entry.Type]
+	if label == "" {
+		label = entry.Type
+	}
+	summary := fmt.Sprintf("%s %sh", label, formatHours(hours))
+	if entry.Meeting {
+		summary = "Meeting"
+	} else if entry.RideCount != nil && *entry.RideCount > 0 {
+		summary = fmt.Sprintf("%s %d rides", label, *entry.RideCount)
+	}
+	if entry.Customer != nil && *entry.Customer != "" {
+		summary += " - " + *entry.Customer
+	}
+
+	event := ics.Event{
+		UID:     fmt.Sprintf("entry-%d%s", entry.ID, feedUIDDomain),
+		Summary: summary,
+		Status:  "CONFIRMED",
+	}
+	if entry.Notes != nil {
+		event.Description = *entry.Notes
+	}
+	eventTimes(&event, entry.Date, entry.Time, hours)
+	return event
+}
+
+func lessonEvent(lesson db.ScheduledLesson) ics.Event {
+	summary := fmt.Sprintf("Scheduled %s %sh", strings.ToLower(typeLabels[lesson.Type]), formatHours(lesson.PlannedHours))
+	if lesson.Customer != nil && *lesson.Customer != "" {
+		summary += " - " + *lesson.Customer
+	}
+
+	event := ics.Event{
+		UID:     fmt.Sprintf("lesson-%d%s", lesson.ID, feedUIDDomain),
+		Summary: summary,
+		Status:  "CONFIRMED",
+	}
+	if lesson.Notes != nil {
+		event.Description = *lesson.Notes
+	}
+	eventTimes(&event, lesson.Date, lesson.Time, lesson.PlannedHours)
+	return event
+}
+
+var (
+	groundPattern  = regexp.MustCompile(`(?i)\bground\b`)
+	simPattern     = regexp.MustCompile(`(?i)\b(sim|simulator|redbird|ftd)\b`)
+	meetingPattern = regexp.MustCompile(`(?i)\bmeeting\b`)
+	// summaryNoise is everything in an event summary that is not the customer
+	summaryNoise = regexp.MustCompile(`(?i)\b(flight|ground|sim|simulator|redbird|ftd|lesson|session|training|dual|with)\b|w/|[-:|()\[\]]`)
+)
+
+// draftFromEvent pre-fills an entry from a calendar event: the type from
+// keywords in the summary, the customer from what is left of it, and hours
+// from the event length rounded to a tenth.
+func draftFromEvent(event ics.Event) db.DraftEntry {
+	draft := db.DraftEntry{
+		Date:      event.Start.Format("2006-01-02"),
+		Type:      "flight",
+		Hours:     math.Round(event.Hours()*10) / 10,
+		SourceUID: event.UID,
+	}
+	if !event.AllDay {
+		draft.Time = event.Start.Format("15:04")
+	}
+	if draft.SourceUID == "" {
+		draft.SourceUID = fmt.Sprintf("%s|%s", event.Start.Format(time.RFC3339), event.Summary)
+	}
+
+	switch {
+	case meetingPattern.MatchString(event.Summary):
+		draft.Type, draft.Meeting = "admin", true
+	case groundPattern.MatchString(event.Summary):
+		draft.Type = "ground"
+	case simPattern.MatchString(event.Summary):
+		draft.Type = "sim"
+	}
+
+	if !draft.Meeting {
+		customer := strings.Join(strings.Fields(summaryNoise.ReplaceAllString(event.Summary, " ")), " ")
+		if customer != "" {
+			draft.Customer = &customer
+		}
+	}
+	if description := strings.TrimSpace(event.Description); description != "" {
+		draft.Notes = &description
+	}
+	return draft
+}
+
+// setupCalendarImport reads an uploaded .ics file (multipart field "file",
+// or the raw request body) into draft entries. Cancelled events and events
+// from our own feed are left out.
+func setupCalendarImport(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxCalendarUpload)
+		var body io.Reader = r.Body
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			file, _, err := r.FormFile("file")
+			if err != nil {
+				toJSON(w, db.Response{
+					Status:  "ERROR",
+					Message: fmt.Sprintf("Missing calendar file: %v", err),
+				})
+				return
+			}
+			defer file.Close()
+			body = file
+		}
+
+		events, err := ics.Parse(body)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Invalid calendar: %v", err),
+			})
+			return
+		}
+
+		var drafts []db.DraftEntry
+		for _, event := range events {
+			if event.Status == "CANCELLED" || strings.HasSuffix(event.UID, feedUIDDomain) {
+				continue
+			}
+			drafts = append(drafts, draftFromEvent(event))
+		}
+
+		created, skipped, err := database.CreateDrafts(drafts)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to import calendar: %v", err),
+			})
+			return
+		}
+
+		data, _ := json.Marshal(map[string]interface{}{
+			"drafts":  created,
+			"skipped": skipped,
+		})
+		toJSON(w, db.Response{
+			Status:  "OK",
+			Message: fmt.Sprintf("Imported %d draft entries", len(created)),
+			Data:    data,
+		})
+	}
+}
+
+// setupDrafts lists draft entries, pending ones unless ?status= says
+// otherwise ("all" for every draft).
+func setupDrafts(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		status := r.URL.Query().Get("status")
+		switch status {
+		case "":
+			status = "pending"
+		case "all":
+			status = ""
+		}
+
+		drafts, err := database.GetDrafts(status)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to get drafts: %v", err),
+			})
+			return
+		}
+
+		data, _ := json.Marshal(drafts)
+		toJSON(w, db.Response{
+			Status:  "OK",
+			Message: "Drafts retrieved",
+			Data:    data,
+		})
+	}
+}
+
+// setupConfirmDraft makes a draft a real entry. Any entry fields sent
+// override the draft, including "meeting": false to clear a draft's meeting.
+func setupConfirmDraft(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: "Invalid JSON format",
+			})
+			return
+		}
+		var request struct {
+			DraftID int      `json:"draft_id"`
+			Entry   db.Entry `json:"entry"`
+		}
+		// Entry.Meeting is a plain bool, so whether meeting was sent at
+		// all is read separately
+		var sent struct {
+			Entry struct {
+				Meeting *bool `json:"meeting"`
+			} `json:"entry"`
+		}
+		if json.Unmarshal(body, &request) != nil || json.Unmarshal(body, &sent) != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: "Invalid JSON format",
+			})
+			return
+		}
+
+		toJSON(w, database.ConfirmDraft(request.DraftID, request.Entry, sent.Entry.Meeting))
+	}
+}
+
+func setupDiscardDraft(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var request struct {
+			DraftID int `json:"draft_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: "Invalid JSON format",
+			})
+			return
+		}
+
+		toJSON(w, database.DiscardDraft(request.DraftID))
+	}
+}
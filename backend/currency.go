@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/theHousedev/pay-log/backend/database"
+)
+
+// defaultExpiringDays is the look-ahead window when ?days= is not given.
+const defaultExpiringDays = 30
+
+// setupCredentials lists recorded credentials (GET), records one (POST) or
+// corrects one (PUT). Renewals are recorded as new credentials.
+func setupCredentials(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			credentials, err := database.GetCredentials()
+			if err != nil {
+				toJSON(w, db.Response{
+					Status:  "ERROR",
+					Message: fmt.Sprintf("Failed to get credentials: %v", err),
+				})
+				return
+			}
+
+			data, _ := json.Marshal(credentials)
+			toJSON(w, db.Response{
+				Status:  "OK",
+				Message: "Credentials retrieved",
+				Data:    data,
+			})
+
+		case http.MethodPost, http.MethodPut:
+			var credential db.Credential
+			if err := json.NewDecoder(r.Body).Decode(&credential); err != nil {
+				toJSON(w, db.Response{
+					Status:  "ERROR",
+					Message: "Invalid JSON format",
+				})
+				return
+			}
+
+			if r.Method == http.MethodPost {
+				toJSON(w, database.CreateCredential(credential))
+			} else {
+				toJSON(w, database.UpdateCredential(credential))
+			}
+
+		default:
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// setupCurrency reports every credential and landing currency as of today
+// (or ?date=). With expiringOnly set it reports just what is expired or
+// lapses within ?days= (30 by default).
+func setupCurrency(database *db.Database, expiringOnly bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		days := defaultExpiringDays
+		if raw := r.URL.Query().Get("days"); raw != "" {
+			value, err := strconv.Atoi(raw)
+			if err != nil || value < 0 {
+				toJSON(w, db.Response{
+					Status:  "ERROR",
+					Message: fmt.Sprintf("Invalid days '%s'", raw),
+				})
+				return
+			}
+			days = value
+		}
+
+		asOf := time.Now().In(time.Local)
+		asOf = time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, time.UTC)
+		if raw := r.URL.Query().Get("date"); raw != "" {
+			parsed, err := time.Parse("2006-01-02", raw)
+			if err != nil {
+				toJSON(w, db.Response{
+					Status:  "ERROR",
+					Message: fmt.Sprintf("Invalid date '%s', expected YYYY-MM-DD", raw),
+				})
+				return
+			}
+			asOf = parsed
+		}
+
+		items, err := database.GetCurrencyReport(asOf, days)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to get currency: %v", err),
+			})
+			return
+		}
+
+		message := fmt.Sprintf("Currency as of %s", asOf.Format("2006-01-02"))
+		if expiringOnly {
+			expiring := []db.CurrencyItem{}
+			for _, item := range items {
+				if item.Status != "current" {
+					expiring = append(expiring, item)
+				}
+			}
+			items = expiring
+			message = fmt.Sprintf("Expired or expiring within %d days of %s", days, asOf.Format("2006-01-02"))
+		}
+
+		data, _ := json.Marshal(items)
+		toJSON(w, db.Response{
+			Status:  "OK",
+			Message: message,
+			Data:    data,
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	db "github.com/theHousedev/pay-log/backend/database"
+	"github.com/theHousedev/pay-log/backend/report"
+)
+
+// setupCustomers lists customers (GET, with optional q/active/limit for
+// autocomplete), creates them (POST) and updates them (PUT).
+func setupCustomers(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			query := r.URL.Query()
+
+			var active *bool
+			if raw := query.Get("active"); raw != "" && raw != "all" {
+				value, err := strconv.ParseBool(raw)
+				if err != nil {
+					toJSON(w, db.Response{
+						Status:  "ERROR",
+						Message: fmt.Sprintf("Invalid active filter '%s'", raw),
+					})
+					return
+				}
+				active = &value
+			}
+
+			limit, _ := strconv.Atoi(query.Get("limit"))
+			customers, err := database.GetCustomers(strings.TrimSpace(query.Get("q")), active, limit)
+			if err != nil {
+				toJSON(w, db.Response{
+					Status:  "ERROR",
+					Message: fmt.Sprintf("Failed to get customers: %v", err),
+				})
+				return
+			}
+
+			data, _ := json.Marshal(customers)
+			toJSON(w, db.Response{
+				Status:  "OK",
+				Message: "Customers retrieved",
+				Data:    data,
+			})
+
+		case http.MethodPost, http.MethodPut:
+			customer := db.Customer{Active: true}
+			if err := json.NewDecoder(r.Body).Decode(&customer); err != nil {
+				toJSON(w, db.Response{
+					Status:  "ERROR",
+					Message: "Invalid JSON format",
+				})
+				return
+			}
+
+			if r.Method == http.MethodPost {
+				toJSON(w, database.CreateCustomer(customer))
+			} else {
+				toJSON(w, database.UpdateCustomer(customer))
+			}
+
+		default:
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func setupAddCustomerAlias(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var request struct {
+			CustomerID int    `json:"customer_id"`
+			Alias      string `json:"alias"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: "Invalid JSON format",
+			})
+			return
+		}
+
+		toJSON(w, database.AddCustomerAlias(request.CustomerID, request.Alias))
+	}
+}
+
+func setupMergeCustomers(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var request struct {
+			SourceID int `json:"source_id"`
+			TargetID int `json:"target_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: "Invalid JSON format",
+			})
+			return
+		}
+
+		toJSON(w, database.MergeCustomers(request.SourceID, request.TargetID))
+	}
+}
+
+// setupCustomerHistory returns a customer's entries, accepting the same
+// filter and paging parameters as /api/get-entries.
+func setupCustomerHistory(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		customerID, err := strconv.Atoi(r.URL.Query().Get("id"))
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: "Invalid customer ID",
+			})
+			return
+		}
+
+		filter, err := parseEntryFilter(r)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: err.Error(),
+			})
+			return
+		}
+		filter.CustomerID = customerID
+
+		entries, total, err := database.FetchFilteredEntries(filter)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to get entries: %v", err),
+			})
+			return
+		}
+
+		data, _ := json.Marshal(entries)
+		toJSON(w, db.Response{
+			Status:  "OK",
+			Message: fmt.Sprintf("Entries retrieved for customer ID=%d", customerID),
+			Data:    data,
+			Pagination: &db.Pagination{
+				Total:  total,
+				Limit:  filter.Limit,
+				Offset: filter.Offset,
+			},
+		})
+	}
+}
+
+func setupCustomerTotals(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		customerID, err := strconv.Atoi(r.URL.Query().Get("id"))
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: "Invalid customer ID",
+			})
+			return
+		}
+
+		customer, err := database.GetCustomer(customerID)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to get customer: %v", err),
+			})
+			return
+		}
+
+		totals, err := database.GetCustomerTotals(customerID)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to calculate totals: %v", err),
+			})
+			return
+		}
+		totals["customer"] = customer.Name
+
+		data, _ := json.Marshal(totals)
+		toJSON(w, db.Response{
+			Status:  "OK",
+			Message: fmt.Sprintf("Totals retrieved for %s", customer.Name),
+			Data:    data,
+		})
+	}
+}
+
+// setupCustomerProgress reports a customer's cumulative training time as
+// JSON, or as a download when format=csv or format=pdf.
+func setupCustomerProgress(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		customerID, err := strconv.Atoi(r.URL.Query().Get("id"))
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: "Invalid customer ID",
+			})
+			return
+		}
+
+		progress, err := database.GetCustomerProgress(customerID)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to get progress: %v", err),
+			})
+			return
+		}
+
+		filename := fmt.Sprintf("progress-%s", slugify(progress.Customer))
+
+		switch r.URL.Query().Get("format") {
+		case "", "json":
+			data, _ := json.Marshal(progress)
+			toJSON(w, db.Response{
+				Status:  "OK",
+				Message: fmt.Sprintf("Progress retrieved for %s", progress.Customer),
+				Data:    data,
+			})
+
+		case "csv":
+			attachment(w, "text/csv", filename+".csv")
+			writer := csv.NewWriter(w)
+			writer.Write([]string{"date", "type", "flight_hours", "ground_hours", "sim_hours",
+				"cumulative_flight", "cumulative_ground", "cumulative_sim", "notes"})
+			for _, point := range progress.Timeline {
+				writer.Write([]string{
+					point.Date, point.Type,
+					formatHours(point.FlightHours), formatHours(point.GroundHours), formatHours(point.SimHours),
+					formatHours(point.CumulativeFlight), formatHours(point.CumulativeGround),
+					formatHours(point.CumulativeSim), point.Notes,
+				})
+			}
+			writer.Flush()
+
+		case "pdf":
+			attachment(w, "application/pdf", filename+".pdf")
+			w.Write(progressPDF(progress))
+
+		default:
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: "Invalid format. Use: json, csv, or pdf",
+			})
+		}
+	}
+}
+
+func progressPDF(progress db.CustomerProgress) []byte {
+	doc := report.NewPDF()
+	doc.Heading(fmt.Sprintf("Training Progress: %s", progress.Customer))
+	doc.Linef("Generated %s", time.Now().In(time.Local).Format("2006-01-02"))
+	doc.Blank()
+	doc.Linef("Lessons:       %d", progress.Lessons)
+	doc.Linef("First lesson:  %s", progress.FirstLesson)
+	doc.Linef("Last lesson:   %s", progress.LastLesson)
+	doc.Linef("Dual flight:   %s", formatHours(progress.FlightHours))
+	doc.Linef("Ground:        %s", formatHours(progress.GroundHours))
+	doc.Linef("Sim:           %s", formatHours(progress.SimHours))
+	doc.Linef("Total:         %s", formatHours(progress.TotalHours))
+	doc.Blank()
+
+	doc.Heading(fmt.Sprintf("%-10s  %-6s  %6s  %6s  %6s  %8s  %8s  %8s  %s",
+		"Date", "Type", "Flight", "Ground", "Sim", "Cum Flt", "Cum Gnd", "Cum Sim", "Notes"))
+	doc.Rule()
+	for _, point := range progress.Timeline {
+		doc.Linef("%-10s  %-6s  %6s  %6s  %6s  %8s  %8s  %8s  %s",
+			point.Date, point.Type,
+			formatHours(point.FlightHours), formatHours(point.GroundHours), formatHours(point.SimHours),
+			formatHours(point.CumulativeFlight), formatHours(point.CumulativeGround),
+			formatHours(point.CumulativeSim), point.Notes)
+	}
+	return doc.Bytes()
+}
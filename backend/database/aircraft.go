@@ -0,0 +1,260 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+const aircraftColumns = `id, tail_number, COALESCE(make_model, ''), COALESCE(category, ''),
+	hourly_rate, active, COALESCE(created_at, '')`
+
+func scanAircraft(row rowScanner) (Aircraft, error) {
+	var aircraft Aircraft
+	err := row.Scan(&aircraft.ID, &aircraft.TailNumber, &aircraft.MakeModel, &aircraft.Category,
+		&aircraft.HourlyRate, &aircraft.Active, &aircraft.CreatedAt)
+	return aircraft, err
+}
+
+func normalizeAircraft(aircraft *Aircraft) string {
+	aircraft.TailNumber = strings.ToUpper(strings.TrimSpace(aircraft.TailNumber))
+	aircraft.MakeModel = strings.TrimSpace(aircraft.MakeModel)
+	aircraft.Category = strings.ToLower(strings.TrimSpace(aircraft.Category))
+	if aircraft.TailNumber == "" {
+		return "Tail number is required"
+	}
+	if aircraft.HourlyRate != nil && *aircraft.HourlyRate < 0 {
+		return "Hourly rate cannot be negative"
+	}
+	return ""
+}
+
+func (database *Database) CreateAircraft(aircraft Aircraft) Response {
+	if msg := normalizeAircraft(&aircraft); msg != "" {
+		return Response{
+			Status:  "ERROR",
+			Message: msg,
+		}
+	}
+
+	result, err := database.Exec(`
+		INSERT INTO aircraft (tail_number, make_model, category, hourly_rate, active)
+		VALUES (?, ?, ?, ?, ?)
+	`, aircraft.TailNumber, nullIfEmpty(aircraft.MakeModel), nullIfEmpty(aircraft.Category),
+		nilCheck(aircraft.HourlyRate), aircraft.Active)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			return Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Aircraft '%s' is already registered", aircraft.TailNumber),
+			}
+		}
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("error creating aircraft: %v", err),
+		}
+	}
+	newID, err := result.LastInsertId()
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("created aircraft, ID error: %v", err),
+		}
+	}
+
+	log.Printf("Created aircraft ID: %d (%s)\n", newID, aircraft.TailNumber)
+	return Response{
+		Status:  "OK",
+		Message: "New aircraft created:",
+		Data:    json.RawMessage(fmt.Sprintf(`{"aircraft_id": %d}`, newID)),
+	}
+}
+
+// UpdateAircraft edits a registered aircraft. Changing the tail number
+// also rewrites it on the aircraft's flight entries.
+func (database *Database) UpdateAircraft(aircraft Aircraft) Response {
+	if msg := normalizeAircraft(&aircraft); msg != "" {
+		return Response{
+			Status:  "ERROR",
+			Message: msg,
+		}
+	}
+
+	tx, err := database.Begin()
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("error starting update: %v", err),
+		}
+	}
+	defer tx.Rollback()
+
+	var oldTail string
+	err = tx.QueryRow("SELECT tail_number FROM aircraft WHERE id = ?", aircraft.ID).Scan(&oldTail)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to find aircraft ID=%d", aircraft.ID),
+		}
+	}
+
+	// a rename onto another registered tail is refused before anything is
+	// written, rather than failing on the UNIQUE constraint
+	var taken int
+	err = tx.QueryRow("SELECT COUNT(*) FROM aircraft WHERE UPPER(tail_number) = UPPER(?) AND id != ?",
+		aircraft.TailNumber, aircraft.ID).Scan(&taken)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to update aircraft ID=%d: %s", aircraft.ID, err),
+		}
+	}
+	if taken > 0 {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Aircraft '%s' is already registered", aircraft.TailNumber),
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE aircraft SET tail_number = ?, make_model = ?, category = ?, hourly_rate = ?, active = ?
+		WHERE id = ?
+	`, aircraft.TailNumber, nullIfEmpty(aircraft.MakeModel), nullIfEmpty(aircraft.Category),
+		nilCheck(aircraft.HourlyRate), aircraft.Active, aircraft.ID)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to update aircraft ID=%d: %s", aircraft.ID, err),
+		}
+	}
+
+	if !strings.EqualFold(oldTail, aircraft.TailNumber) {
+		_, err = tx.Exec("UPDATE pay_entries SET tail_number = ? WHERE UPPER(tail_number) = UPPER(?)",
+			aircraft.TailNumber, oldTail)
+		if err != nil {
+			return Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Unable to retag entries for aircraft ID=%d: %s", aircraft.ID, err),
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to update aircraft ID=%d: %s", aircraft.ID, err),
+		}
+	}
+
+	log.Printf("Updated aircraft ID: %d\n", aircraft.ID)
+	return Response{
+		Status:  "OK",
+		Message: "Updated aircraft:",
+		Data:    json.RawMessage(fmt.Sprintf(`{"aircraft_id": %d}`, aircraft.ID)),
+	}
+}
+
+// GetAircraft lists registered aircraft by tail number, optionally only
+// active or inactive ones.
+func (database *Database) GetAircraft(active *bool) ([]Aircraft, error) {
+	query := "SELECT " + aircraftColumns + " FROM aircraft"
+	var args []interface{}
+	if active != nil {
+		query += " WHERE active = ?"
+		args = append(args, *active)
+	}
+	query += " ORDER BY tail_number"
+
+	rows, err := database.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get aircraft: %w", err)
+	}
+	defer rows.Close()
+
+	fleet := []Aircraft{}
+	for rows.Next() {
+		aircraft, err := scanAircraft(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan aircraft: %w", err)
+		}
+		fleet = append(fleet, aircraft)
+	}
+	return fleet, rows.Err()
+}
+
+// GetAircraftHours totals flight entries per tail number over a date range
+// ("all" for unbounded), or for one tail when given. Flights without a tail
+// number are left out. Results are ordered by hours, most first.
+func (database *Database) GetAircraftHours(startDate, endDate, tail string) ([]AircraftHours, error) {
+	entries, _, err := database.FetchFilteredEntries(EntryFilter{
+		BeginDate: startDate,
+		EndDate:   endDate,
+		Types:     []string{"flight"},
+		Tail:      tail,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fleet, err := database.GetAircraft(nil)
+	if err != nil {
+		return nil, err
+	}
+	registry := map[string]Aircraft{}
+	for _, aircraft := range fleet {
+		registry[aircraft.TailNumber] = aircraft
+	}
+
+	byTail := map[string]*AircraftHours{}
+	for _, entry := range entries {
+		if entry.TailNumber == nil {
+			continue
+		}
+		key := strings.ToUpper(*entry.TailNumber)
+		hours, ok := byTail[key]
+		if !ok {
+			hours = &AircraftHours{TailNumber: key}
+			if aircraft, registered := registry[key]; registered {
+				hours.MakeModel = aircraft.MakeModel
+				hours.Category = aircraft.Category
+				hours.Registered = true
+			} else if entry.AircraftType != nil {
+				hours.MakeModel = *entry.AircraftType
+			}
+			byTail[key] = hours
+		}
+
+		hours.Flights++
+		hours.Hours += nilFloat(entry.FlightHours)
+		hours.NightHours += nilFloat(entry.NightHours)
+		hours.IFRHours += nilFloat(entry.IFRHours)
+		hours.XCHours += nilFloat(entry.XCHours)
+		if entry.DayLandings != nil {
+			hours.Landings += *entry.DayLandings
+		}
+		if entry.NightLandings != nil {
+			hours.Landings += *entry.NightLandings
+		}
+		if date := strings.Split(entry.Date, "T")[0]; date > hours.LastFlown {
+			hours.LastFlown = date
+		}
+	}
+
+	report := make([]AircraftHours, 0, len(byTail))
+	for tail, hours := range byTail {
+		if aircraft, registered := registry[tail]; registered && aircraft.HourlyRate != nil {
+			cost := hours.Hours * *aircraft.HourlyRate
+			hours.Cost = &cost
+		}
+		report = append(report, *hours)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Hours != report[j].Hours {
+			return report[i].Hours > report[j].Hours
+		}
+		return report[i].TailNumber < report[j].TailNumber
+	})
+	return report, nil
+}
@@ -0,0 +1,279 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+var credentialKinds = map[string]bool{
+	"certificate":   true,
+	"rating":        true,
+	"cfi":           true,
+	"flight_review": true,
+	"medical":       true,
+	"other":         true,
+}
+
+// landingCurrencyDays is the look-back window for carrying passengers:
+// three takeoffs and landings (full-stop at night) in the preceding 90 days.
+const landingCurrencyDays = 90
+
+const credentialColumns = `id, kind, name, number, issued_date, expires_date, medical_class, notes,
+	COALESCE(created_at, '')`
+
+func scanCredential(row rowScanner) (Credential, error) {
+	var credential Credential
+	err := row.Scan(&credential.ID, &credential.Kind, &credential.Name, &credential.Number,
+		&credential.IssuedDate, &credential.ExpiresDate, &credential.MedicalClass, &credential.Notes,
+		&credential.CreatedAt)
+	for _, date := range []*string{credential.IssuedDate, credential.ExpiresDate} {
+		if date != nil {
+			*date = strings.Split(*date, "T")[0]
+		}
+	}
+	return credential, err
+}
+
+// endOfCalendarMonths is the last day of the month n calendar months after
+// date, the way flight reviews and CFI certificates lapse.
+func endOfCalendarMonths(date time.Time, n int) time.Time {
+	firstOfMonth := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
+	return firstOfMonth.AddDate(0, n+1, -1)
+}
+
+// prepareCredential validates a credential and fills in the expiration of
+// a flight review or CFI certificate from its issue date when not given.
+func prepareCredential(credential *Credential) string {
+	credential.Kind = strings.ToLower(strings.TrimSpace(credential.Kind))
+	credential.Name = strings.TrimSpace(credential.Name)
+	if !credentialKinds[credential.Kind] {
+		return "Invalid credential kind. Use: certificate, rating, cfi, flight_review, medical, or other"
+	}
+	if credential.Name == "" {
+		credential.Name = strings.ReplaceAll(credential.Kind, "_", " ")
+	}
+
+	for _, date := range []*string{credential.IssuedDate, credential.ExpiresDate} {
+		if date == nil {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", *date); err != nil {
+			return fmt.Sprintf("Invalid date '%s', expected YYYY-MM-DD", *date)
+		}
+	}
+
+	if credential.MedicalClass != nil {
+		if credential.Kind != "medical" {
+			return "Medical class only applies to medicals"
+		}
+		if *credential.MedicalClass < 1 || *credential.MedicalClass > 3 {
+			return "Medical class must be 1, 2, or 3"
+		}
+	}
+
+	if credential.ExpiresDate == nil && credential.IssuedDate != nil &&
+		(credential.Kind == "flight_review" || credential.Kind == "cfi") {
+		issued, _ := time.Parse("2006-01-02", *credential.IssuedDate)
+		expires := endOfCalendarMonths(issued, 24).Format("2006-01-02")
+		credential.ExpiresDate = &expires
+	}
+	return ""
+}
+
+func (database *Database) CreateCredential(credential Credential) Response {
+	if msg := prepareCredential(&credential); msg != "" {
+		return Response{
+			Status:  "ERROR",
+			Message: msg,
+		}
+	}
+
+	result, err := database.Exec(`
+		INSERT INTO credentials (kind, name, number, issued_date, expires_date, medical_class, notes)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, credential.Kind, credential.Name, nilCheck(credential.Number), nilCheck(credential.IssuedDate),
+		nilCheck(credential.ExpiresDate), nilCheck(credential.MedicalClass), nilCheck(credential.Notes))
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("error creating credential: %v", err),
+		}
+	}
+	newID, err := result.LastInsertId()
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("created credential, ID error: %v", err),
+		}
+	}
+
+	log.Printf("Created credential ID: %d\n", newID)
+	return Response{
+		Status:  "OK",
+		Message: "New credential created:",
+		Data:    json.RawMessage(fmt.Sprintf(`{"credential_id": %d}`, newID)),
+	}
+}
+
+func (database *Database) UpdateCredential(credential Credential) Response {
+	if msg := prepareCredential(&credential); msg != "" {
+		return Response{
+			Status:  "ERROR",
+			Message: msg,
+		}
+	}
+
+	result, err := database.Exec(`
+		UPDATE credentials
+		SET kind = ?, name = ?, number = ?, issued_date = ?, expires_date = ?, medical_class = ?, notes = ?
+		WHERE id = ?
+	`, credential.Kind, credential.Name, nilCheck(credential.Number), nilCheck(credential.IssuedDate),
+		nilCheck(credential.ExpiresDate), nilCheck(credential.MedicalClass), nilCheck(credential.Notes),
+		credential.ID)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to update credential ID=%d: %s", credential.ID, err),
+		}
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to find credential ID=%d", credential.ID),
+		}
+	}
+
+	log.Printf("Updated credential ID: %d\n", credential.ID)
+	return Response{
+		Status:  "OK",
+		Message: "Updated credential:",
+		Data:    json.RawMessage(fmt.Sprintf(`{"credential_id": %d}`, credential.ID)),
+	}
+}
+
+// GetCredentials lists every credential recorded, renewals included,
+// soonest expiring first.
+func (database *Database) GetCredentials() ([]Credential, error) {
+	rows, err := database.Query("SELECT " + credentialColumns +
+		" FROM credentials ORDER BY expires_date IS NULL, expires_date, kind, name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credentials: %w", err)
+	}
+	defer rows.Close()
+
+	credentials := []Credential{}
+	for rows.Next() {
+		credential, err := scanCredential(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan credential: %w", err)
+		}
+		credentials = append(credentials, credential)
+	}
+	return credentials, rows.Err()
+}
+
+func currencyStatus(item *CurrencyItem, asOf time.Time, expires time.Time, withinDays int) {
+	days := int(expires.Sub(asOf).Hours() / 24)
+	item.ExpiresDate = expires.Format("2006-01-02")
+	item.DaysRemaining = &days
+	switch {
+	case days < 0:
+		item.Status = "expired"
+	case days <= withinDays:
+		item.Status = "expiring"
+	default:
+		item.Status = "current"
+	}
+}
+
+// landingCurrency finds when the most recent three landings (night only
+// when night is true) were reached, and so when passenger-carrying
+// currency lapses.
+func (database *Database) landingCurrency(asOf time.Time, night bool, withinDays int) (CurrencyItem, error) {
+	item := CurrencyItem{Kind: "landing_currency", Name: "Day passenger currency"}
+	if night {
+		item.Name = "Night passenger currency"
+	}
+
+	entries, _, err := database.FetchFilteredEntries(EntryFilter{
+		EndDate:  asOf.Format("2006-01-02"),
+		Types:    []string{"flight"},
+		SortBy:   "date",
+		SortDesc: true,
+	})
+	if err != nil {
+		return item, err
+	}
+
+	landings := 0
+	for _, entry := range entries {
+		if entry.NightLandings != nil {
+			landings += *entry.NightLandings
+		}
+		if !night && entry.DayLandings != nil {
+			landings += *entry.DayLandings
+		}
+		if landings >= 3 {
+			date, _ := time.Parse("2006-01-02", strings.Split(entry.Date, "T")[0])
+			currencyStatus(&item, asOf, date.AddDate(0, 0, landingCurrencyDays), withinDays)
+			item.Detail = fmt.Sprintf("3rd most recent landing on %s", date.Format("2006-01-02"))
+			return item, nil
+		}
+	}
+
+	item.Status = "expired"
+	item.Detail = fmt.Sprintf("only %d qualifying landings logged", landings)
+	return item, nil
+}
+
+// GetCurrencyReport reports every credential and the day/night landing
+// currency as of asOf, with anything lapsing within withinDays marked
+// expiring. Of several credentials with the same kind and name (renewals)
+// only the one that lasts longest is reported.
+func (database *Database) GetCurrencyReport(asOf time.Time, withinDays int) ([]CurrencyItem, error) {
+	credentials, err := database.GetCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	latest := map[string]Credential{}
+	var order []string
+	for _, credential := range credentials {
+		key := credential.Kind + "|" + strings.ToLower(credential.Name)
+		held, seen := latest[key]
+		if !seen {
+			order = append(order, key)
+		}
+		if !seen || held.ExpiresDate != nil &&
+			(credential.ExpiresDate == nil || *credential.ExpiresDate > *held.ExpiresDate) {
+			latest[key] = credential
+		}
+	}
+
+	items := []CurrencyItem{}
+	for _, key := range order {
+		credential := latest[key]
+		id := credential.ID
+		item := CurrencyItem{Kind: credential.Kind, Name: credential.Name, CredentialID: &id, Status: "current"}
+		if credential.MedicalClass != nil {
+			item.Detail = fmt.Sprintf("class %d", *credential.MedicalClass)
+		}
+		if credential.ExpiresDate != nil {
+			expires, _ := time.Parse("2006-01-02", *credential.ExpiresDate)
+			currencyStatus(&item, asOf, expires, withinDays)
+		}
+		items = append(items, item)
+	}
+
+	for _, night := range []bool{false, true} {
+		item, err := database.landingCurrency(asOf, night, withinDays)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
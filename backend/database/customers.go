@@ -0,0 +1,563 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+const findCustomerSQL = `
+	SELECT id, name FROM customers WHERE name = ?
+	UNION
+	SELECT c.id, c.name FROM customer_aliases a
+	JOIN customers c ON c.id = a.customer_id
+	WHERE a.alias = ?
+	LIMIT 1
+`
+
+// ResolveCustomer maps a free-text customer onto its canonical customer,
+// creating one when the name or alias is not known yet. Blank names resolve
+// to nil so entries without a customer stay unlinked.
+func (database *Database) ResolveCustomer(name *string) (*int, *string, error) {
+	if name == nil || strings.TrimSpace(*name) == "" {
+		return nil, name, nil
+	}
+	trimmed := strings.TrimSpace(*name)
+
+	var id int
+	var canonical string
+	err := database.QueryRow(findCustomerSQL, trimmed, trimmed).Scan(&id, &canonical)
+	if err == nil {
+		return &id, &canonical, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, nil, fmt.Errorf("failed to look up customer: %w", err)
+	}
+
+	result, err := database.Exec("INSERT INTO customers (name) VALUES (?)", trimmed)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create customer: %w", err)
+	}
+	newID, err := result.LastInsertId()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get new customer ID: %w", err)
+	}
+	id = int(newID)
+	log.Printf("Created customer ID: %d (%s)\n", id, trimmed)
+	return &id, &trimmed, nil
+}
+
+// linkEntryCustomers attaches customer rows to entries logged before the
+// customers table existed.
+func (database *Database) linkEntryCustomers() error {
+	rows, err := database.Query(`
+		SELECT DISTINCT customer FROM pay_entries
+		WHERE customer_id IS NULL AND TRIM(COALESCE(customer, '')) != ''
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to read unlinked customers: %w", err)
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan customer: %w", err)
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+
+	for _, name := range names {
+		id, canonical, err := database.ResolveCustomer(&name)
+		if err != nil {
+			return err
+		}
+		_, err = database.Exec(
+			"UPDATE pay_entries SET customer_id = ?, customer = ? WHERE customer = ? AND customer_id IS NULL",
+			*id, *canonical, name,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to link entries for '%s': %w", name, err)
+		}
+	}
+
+	if len(names) > 0 {
+		log.Printf("Linked %d customer names to customer records\n", len(names))
+	}
+	return nil
+}
+
+// GetCustomers lists customers whose name or alias contains search. A nil
+// active returns both active and inactive customers; limit 0 is unlimited.
+func (database *Database) GetCustomers(search string, active *bool, limit int) ([]Customer, error) {
+	query := `
+		SELECT c.id, c.name, c.active, c.created_at,
+		       (SELECT COUNT(*) FROM pay_entries e WHERE e.customer_id = c.id)
+		FROM customers c
+		WHERE 1 = 1
+	`
+	var args []interface{}
+
+	if search != "" {
+		query += ` AND (c.name LIKE '%' || ? || '%' ESCAPE '\' OR c.id IN (
+			SELECT customer_id FROM customer_aliases WHERE alias LIKE '%' || ? || '%' ESCAPE '\'))`
+		pattern := likeEscaper.Replace(search)
+		args = append(args, pattern, pattern)
+	}
+	if active != nil {
+		query += " AND c.active = ?"
+		args = append(args, *active)
+	}
+	query += " ORDER BY c.name COLLATE NOCASE"
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := database.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customers: %w", err)
+	}
+	defer rows.Close()
+
+	customers := []Customer{}
+	for rows.Next() {
+		var customer Customer
+		err := rows.Scan(&customer.ID, &customer.Name, &customer.Active,
+			&customer.CreatedAt, &customer.EntryCount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan customer: %w", err)
+		}
+		customers = append(customers, customer)
+	}
+	rows.Close()
+
+	for i := range customers {
+		customers[i].Aliases, err = database.getCustomerAliases(customers[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return customers, nil
+}
+
+func (database *Database) GetCustomer(id int) (Customer, error) {
+	var customer Customer
+	err := database.QueryRow(`
+		SELECT c.id, c.name, c.active, c.created_at,
+		       (SELECT COUNT(*) FROM pay_entries e WHERE e.customer_id = c.id)
+		FROM customers c WHERE c.id = ?
+	`, id).Scan(&customer.ID, &customer.Name, &customer.Active,
+		&customer.CreatedAt, &customer.EntryCount)
+	if err != nil {
+		return Customer{}, fmt.Errorf("failed to get customer ID=%d: %w", id, err)
+	}
+
+	customer.Aliases, err = database.getCustomerAliases(id)
+	if err != nil {
+		return Customer{}, err
+	}
+	return customer, nil
+}
+
+func (database *Database) getCustomerAliases(customerID int) ([]string, error) {
+	rows, err := database.Query(
+		"SELECT alias FROM customer_aliases WHERE customer_id = ? ORDER BY alias COLLATE NOCASE",
+		customerID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get aliases: %w", err)
+	}
+	defer rows.Close()
+
+	aliases := []string{}
+	for rows.Next() {
+		var alias string
+		if err := rows.Scan(&alias); err != nil {
+			return nil, fmt.Errorf("failed to scan alias: %w", err)
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases, nil
+}
+
+// findCustomer looks name up as a customer name or alias, returning the
+// owning customer's ID and canonical name when found.
+func (database *Database) findCustomer(name string) (int, string, bool, error) {
+	var id int
+	var canonical string
+	err := database.QueryRow(findCustomerSQL, name, name).Scan(&id, &canonical)
+	if err == sql.ErrNoRows {
+		return 0, "", false, nil
+	}
+	if err != nil {
+		return 0, "", false, fmt.Errorf("failed to look up customer: %w", err)
+	}
+	return id, canonical, true, nil
+}
+
+// customerNameTaken reports whether name is already used as a customer name
+// or alias by a customer other than excludeID.
+func (database *Database) customerNameTaken(name string, excludeID int) (bool, error) {
+	id, _, found, err := database.findCustomer(name)
+	return found && id != excludeID, err
+}
+
+func (database *Database) CreateCustomer(customer Customer) Response {
+	name := strings.TrimSpace(customer.Name)
+	if name == "" {
+		return Response{
+			Status:  "ERROR",
+			Message: "Customer name is required",
+		}
+	}
+
+	taken, err := database.customerNameTaken(name, 0)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: err.Error(),
+		}
+	}
+	if taken {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Customer name '%s' is already in use", name),
+		}
+	}
+
+	result, err := database.Exec("INSERT INTO customers (name, active) VALUES (?, ?)", name, customer.Active)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("error creating customer: %v", err),
+		}
+	}
+	newID, err := result.LastInsertId()
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("created customer, ID error: %v", err),
+		}
+	}
+
+	for _, alias := range customer.Aliases {
+		if response := database.AddCustomerAlias(int(newID), alias); response.Status != "OK" {
+			return response
+		}
+	}
+
+	log.Printf("Created customer ID: %d\n", newID)
+	return Response{
+		Status:  "OK",
+		Message: "New customer created:",
+		Data:    json.RawMessage(fmt.Sprintf(`{"customer_id": %d}`, newID)),
+	}
+}
+
+// UpdateCustomer renames a customer and/or changes its active status.
+// Renaming also rewrites the customer text on its entries and lessons.
+func (database *Database) UpdateCustomer(customer Customer) Response {
+	name := strings.TrimSpace(customer.Name)
+	if name == "" {
+		return Response{
+			Status:  "ERROR",
+			Message: "Customer name is required",
+		}
+	}
+
+	taken, err := database.customerNameTaken(name, customer.ID)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: err.Error(),
+		}
+	}
+	if taken {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Customer name '%s' is already in use", name),
+		}
+	}
+
+	tx, err := database.Begin()
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("error starting update: %v", err),
+		}
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE customers SET name = ?, active = ? WHERE id = ?",
+		name, customer.Active, customer.ID)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to update customer ID=%d: %s", customer.ID, err),
+		}
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to find customer ID=%d", customer.ID),
+		}
+	}
+
+	_, err = tx.Exec("UPDATE pay_entries SET customer = ? WHERE customer_id = ?", name, customer.ID)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to rename entries for customer ID=%d: %s", customer.ID, err),
+		}
+	}
+
+	_, err = tx.Exec("UPDATE scheduled_lessons SET customer = ? WHERE customer_id = ?", name, customer.ID)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to rename lessons for customer ID=%d: %s", customer.ID, err),
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to update customer ID=%d: %s", customer.ID, err),
+		}
+	}
+
+	log.Printf("Updated customer ID: %d\n", customer.ID)
+	return Response{
+		Status:  "OK",
+		Message: "Updated customer:",
+		Data:    json.RawMessage(fmt.Sprintf(`{"customer_id": %d}`, customer.ID)),
+	}
+}
+
+// AddCustomerAlias lets alias resolve to a customer. When alias is another
+// customer's own name, typically one created from an earlier spelling, that
+// customer is merged into this one; an alias of another customer is refused.
+func (database *Database) AddCustomerAlias(customerID int, alias string) Response {
+	alias = strings.TrimSpace(alias)
+	if alias == "" {
+		return Response{
+			Status:  "ERROR",
+			Message: "Alias is required",
+		}
+	}
+
+	customer, err := database.GetCustomer(customerID)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to find customer ID=%d", customerID),
+		}
+	}
+
+	ownerID, ownerName, found, err := database.findCustomer(alias)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: err.Error(),
+		}
+	}
+	if found && ownerID != customerID {
+		if !strings.EqualFold(ownerName, alias) {
+			return Response{
+				Status: "ERROR",
+				Message: fmt.Sprintf("Alias '%s' already belongs to customer '%s'; merge the two with /api/customers/merge",
+					alias, ownerName),
+			}
+		}
+		// merging makes the absorbed name an alias and moves its entries
+		if response := database.MergeCustomers(ownerID, customerID); response.Status != "OK" {
+			return response
+		}
+	}
+
+	_, err = database.Exec(
+		"INSERT OR IGNORE INTO customer_aliases (customer_id, alias) VALUES (?, ?)",
+		customerID, alias,
+	)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("error adding alias: %v", err),
+		}
+	}
+
+	// entries typed with this alias before it existed get linked now
+	_, err = database.Exec(`
+		UPDATE pay_entries SET customer_id = ?, customer = ?
+		WHERE customer = ? COLLATE NOCASE
+	`, customerID, customer.Name, alias)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("error relinking entries: %v", err),
+		}
+	}
+
+	return Response{
+		Status:  "OK",
+		Message: "Alias added:",
+		Data:    json.RawMessage(fmt.Sprintf(`{"customer_id": %d}`, customerID)),
+	}
+}
+
+// MergeCustomers folds source into target: source's entries and lessons move
+// to target, source's name and aliases become target aliases, and source is
+// removed.
+func (database *Database) MergeCustomers(sourceID, targetID int) Response {
+	if sourceID == targetID {
+		return Response{
+			Status:  "ERROR",
+			Message: "Cannot merge a customer into itself",
+		}
+	}
+
+	source, err := database.GetCustomer(sourceID)
+	if err != nil {
+		return Response{Status: "ERROR", Message: err.Error()}
+	}
+	target, err := database.GetCustomer(targetID)
+	if err != nil {
+		return Response{Status: "ERROR", Message: err.Error()}
+	}
+
+	tx, err := database.Begin()
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("error starting merge: %v", err),
+		}
+	}
+	defer tx.Rollback()
+
+	statements := []struct {
+		query string
+		args  []interface{}
+	}{
+		{"UPDATE customer_aliases SET customer_id = ? WHERE customer_id = ?", []interface{}{targetID, sourceID}},
+		{"INSERT OR IGNORE INTO customer_aliases (customer_id, alias) VALUES (?, ?)", []interface{}{targetID, source.Name}},
+		{"UPDATE pay_entries SET customer_id = ?, customer = ? WHERE customer_id = ?", []interface{}{targetID, target.Name, sourceID}},
+		{"UPDATE scheduled_lessons SET customer_id = ?, customer = ? WHERE customer_id = ?", []interface{}{targetID, target.Name, sourceID}},
+		{"DELETE FROM customers WHERE id = ?", []interface{}{sourceID}},
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement.query, statement.args...); err != nil {
+			return Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Unable to merge customer ID=%d into ID=%d: %s", sourceID, targetID, err),
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to merge customer ID=%d into ID=%d: %s", sourceID, targetID, err),
+		}
+	}
+
+	log.Printf("Merged customer ID %d into %d\n", sourceID, targetID)
+	return Response{
+		Status:  "OK",
+		Message: "Customers merged:",
+		Data:    json.RawMessage(fmt.Sprintf(`{"customer_id": %d, "entries_moved": %d}`, targetID, source.EntryCount)),
+	}
+}
+
+// GetCustomerTotals sums the hours logged for one customer.
+func (database *Database) GetCustomerTotals(customerID int) (map[string]interface{}, error) {
+	query := `
+		SELECT
+			COUNT(*),
+			COALESCE(SUM(flight_hours), 0),
+			COALESCE(SUM(ground_hours), 0),
+			COALESCE(SUM(sim_hours), 0),
+			MIN(date),
+			MAX(date)
+		FROM pay_entries
+		WHERE customer_id = ?
+	`
+
+	var entryCount int
+	var flightHours, groundHours, simHours float64
+	var firstDate, lastDate sql.NullString
+	err := database.QueryRow(query, customerID).Scan(
+		&entryCount, &flightHours, &groundHours, &simHours, &firstDate, &lastDate,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate customer hours: %w", err)
+	}
+
+	return map[string]interface{}{
+		"customer_id":  customerID,
+		"entry_count":  entryCount,
+		"flight_hours": flightHours,
+		"ground_hours": groundHours,
+		"sim_hours":    simHours,
+		"total_hours":  flightHours + groundHours + simHours,
+		"first_date":   strings.Split(firstDate.String, "T")[0],
+		"last_date":    strings.Split(lastDate.String, "T")[0],
+	}, nil
+}
+
+// GetCustomerProgress builds a customer's training record from their
+// flight, ground and sim entries, oldest first.
+func (database *Database) GetCustomerProgress(customerID int) (CustomerProgress, error) {
+	customer, err := database.GetCustomer(customerID)
+	if err != nil {
+		return CustomerProgress{}, err
+	}
+
+	rows, err := database.Query(`
+		SELECT id, date, type,
+		       COALESCE(flight_hours, 0), COALESCE(ground_hours, 0), COALESCE(sim_hours, 0),
+		       COALESCE(notes, '')
+		FROM pay_entries
+		WHERE customer_id = ? AND type IN ('flight', 'ground', 'sim')
+		ORDER BY date ASC, time ASC, id ASC
+	`, customerID)
+	if err != nil {
+		return CustomerProgress{}, fmt.Errorf("failed to get lessons: %w", err)
+	}
+	defer rows.Close()
+
+	progress := CustomerProgress{
+		CustomerID: customer.ID,
+		Customer:   customer.Name,
+		Timeline:   []ProgressPoint{},
+	}
+	for rows.Next() {
+		var point ProgressPoint
+		err := rows.Scan(&point.EntryID, &point.Date, &point.Type,
+			&point.FlightHours, &point.GroundHours, &point.SimHours, &point.Notes)
+		if err != nil {
+			return CustomerProgress{}, fmt.Errorf("failed to scan lesson: %w", err)
+		}
+		point.Date = strings.Split(point.Date, "T")[0]
+
+		progress.FlightHours += point.FlightHours
+		progress.GroundHours += point.GroundHours
+		progress.SimHours += point.SimHours
+		point.CumulativeFlight = progress.FlightHours
+		point.CumulativeGround = progress.GroundHours
+		point.CumulativeSim = progress.SimHours
+
+		if progress.FirstLesson == "" {
+			progress.FirstLesson = point.Date
+		}
+		progress.LastLesson = point.Date
+		progress.Timeline = append(progress.Timeline, point)
+	}
+
+	progress.Lessons = len(progress.Timeline)
+	progress.TotalHours = progress.FlightHours + progress.GroundHours + progress.SimHours
+	return progress, nil
+}
@@ -0,0 +1,125 @@
+package database
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func createdCustomerID(t *testing.T, response Response) int {
+	t.Helper()
+	if response.Status != "OK" {
+		t.Fatal(response.Message)
+	}
+	var created struct {
+		CustomerID int `json:"customer_id"`
+	}
+	if err := json.Unmarshal(response.Data, &created); err != nil {
+		t.Fatal(err)
+	}
+	return created.CustomerID
+}
+
+func TestResolveCustomer(t *testing.T) {
+	database := openTestDB(t)
+	smith := createdCustomerID(t, database.CreateCustomer(Customer{Name: "John Smith", Active: true, Aliases: []string{"Johnny"}}))
+
+	tests := []struct {
+		typed     string
+		wantID    int
+		wantName  string
+		wantFresh bool
+	}{
+		{"John Smith", smith, "John Smith", false},
+		{"  john smith ", smith, "John Smith", false},
+		{"JOHNNY", smith, "John Smith", false},
+		{"Jane Doe", 0, "Jane Doe", true},
+	}
+	for _, tt := range tests {
+		typed := tt.typed
+		id, name, err := database.ResolveCustomer(&typed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.wantFresh && (id == nil || *id == smith) || !tt.wantFresh && (id == nil || *id != tt.wantID) {
+			t.Errorf("ResolveCustomer(%q) ID = %v", tt.typed, id)
+		}
+		if name == nil || *name != tt.wantName {
+			t.Errorf("ResolveCustomer(%q) name = %v, want %s", tt.typed, name, tt.wantName)
+		}
+	}
+
+	blank := "  "
+	if id, _, err := database.ResolveCustomer(&blank); err != nil || id != nil {
+		t.Errorf("blank customer resolved to %v, %v; want unlinked", id, err)
+	}
+}
+
+func TestAddCustomerAlias(t *testing.T) {
+	database := openTestDB(t)
+	smith := createdCustomerID(t, database.CreateCustomer(Customer{Name: "John Smith", Active: true}))
+	doe := createdCustomerID(t, database.CreateCustomer(Customer{Name: "Jane Doe", Active: true, Aliases: []string{"JD"}}))
+
+	// an entry typed with a new spelling creates its own customer
+	typed := "J. Smith"
+	entry := Entry{Type: "flight", Date: "2025-03-03", Time: "08:00", FlightHours: hoursOf(1), Customer: &typed}
+	if response := database.NewEntry(entry); response.Status != "OK" {
+		t.Fatal(response.Message)
+	}
+
+	// aliasing that spelling absorbs the customer it created
+	if response := database.AddCustomerAlias(smith, "j. smith"); response.Status != "OK" {
+		t.Fatalf("aliasing a typed spelling: %s", response.Message)
+	}
+	entries, _, err := database.FetchFilteredEntries(EntryFilter{CustomerID: smith})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || *entries[0].Customer != "John Smith" {
+		t.Errorf("entries for John Smith = %+v, want the J. Smith entry relinked", entries)
+	}
+	customers, err := database.GetCustomers("Smith", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(customers) != 1 || customers[0].ID != smith {
+		t.Errorf("customers matching Smith = %+v, want only John Smith", customers)
+	}
+
+	// another customer's alias is refused with a pointer to merging
+	response := database.AddCustomerAlias(smith, "JD")
+	if response.Status != "ERROR" || !strings.Contains(response.Message, "/api/customers/merge") {
+		t.Errorf("aliasing another customer's alias = %+v, want a merge hint", response)
+	}
+	if owner, _, _, _ := database.findCustomer("JD"); owner != doe {
+		t.Errorf("JD now resolves to customer %d, want it left with %d", owner, doe)
+	}
+
+	// unknown customers are refused before anything is written
+	response = database.AddCustomerAlias(9999, "Ghost")
+	if response.Status != "ERROR" {
+		t.Errorf("alias for an unknown customer = %+v, want an error", response)
+	}
+	if _, _, found, _ := database.findCustomer("Ghost"); found {
+		t.Error("orphan alias stored for an unknown customer")
+	}
+}
+
+func TestCreateCustomerNameTaken(t *testing.T) {
+	database := openTestDB(t)
+	createdCustomerID(t, database.CreateCustomer(Customer{Name: "John Smith", Active: true, Aliases: []string{"Johnny"}}))
+
+	for _, name := range []string{"john smith", "Johnny"} {
+		response := database.CreateCustomer(Customer{Name: name, Active: true})
+		if response.Status != "ERROR" || !strings.Contains(response.Message, "already in use") {
+			t.Errorf("CreateCustomer(%q) = %+v, want already in use", name, response)
+		}
+	}
+
+	// a lookup failure is reported as such, not as a taken name
+	database.Close()
+	response := database.CreateCustomer(Customer{Name: "Jane Doe", Active: true})
+	if response.Status != "ERROR" || strings.Contains(response.Message, "already in use") {
+		t.Errorf("CreateCustomer on a closed database = %+v, want the database error", response)
+	}
+}
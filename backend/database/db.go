@@ -2,7 +2,9 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"log"
 	"os"
 	"strings"
 
@@ -11,6 +13,9 @@ import (
 
 type Database struct {
 	*sql.DB
+
+	// Rounding is applied to each entry's hours by the pay engine.
+	Rounding Rounding
 }
 
 func Connect(path string) (*Database, error) {
@@ -19,10 +24,13 @@ func Connect(path string) (*Database, error) {
 		return nil, fmt.Errorf("error opening database: %w", err)
 	}
 
-	database := &Database{sqlDB}
+	database := &Database{DB: sqlDB}
 	if err := database.createTables(); err != nil {
 		return nil, err
 	}
+	if err := database.migrate(); err != nil {
+		return nil, err
+	}
 	if err := database.UpdatePayPeriodStatus(); err != nil {
 		return nil, fmt.Errorf("failed to update period statuses: %w", err)
 	}
@@ -55,6 +63,50 @@ func (database *Database) createTables() error {
 	return nil
 }
 
+// migrations bring databases created from an older schema.sql up to date.
+// Each statement must be safe to re-run; duplicate columns are skipped.
+var migrations = []string{
+	`ALTER TABLE pay_entries ADD COLUMN customer_id INTEGER REFERENCES customers(id)`,
+	`ALTER TABLE monthly_stats ADD COLUMN total_sim_hours DECIMAL(6,2)`,
+	`ALTER TABLE pay_rates ADD COLUMN overtime_threshold DECIMAL(5,2) DEFAULT NULL`,
+	`ALTER TABLE pay_rates ADD COLUMN overtime_multiplier DECIMAL(4,2) DEFAULT 1.5`,
+	`ALTER TABLE pay_rates ADD COLUMN flight_rate DECIMAL(6,2) DEFAULT NULL`,
+	`ALTER TABLE pay_rates ADD COLUMN ground_rate DECIMAL(6,2) DEFAULT NULL`,
+	`ALTER TABLE pay_rates ADD COLUMN sim_rate DECIMAL(6,2) DEFAULT NULL`,
+	`ALTER TABLE pay_rates ADD COLUMN misc_rate DECIMAL(6,2) DEFAULT NULL`,
+	`ALTER TABLE pay_rates ADD COLUMN meeting_amount DECIMAL(6,2) DEFAULT NULL`,
+	`ALTER TABLE pay_rates ADD COLUMN meeting_hours DECIMAL(4,2) DEFAULT NULL`,
+	`ALTER TABLE pay_rates ADD COLUMN ride_credit_hours DECIMAL(4,2) DEFAULT 0.2`,
+	`ALTER TABLE pay_rates ADD COLUMN ride_credit_amount DECIMAL(6,2) DEFAULT NULL`,
+	`ALTER TABLE pay_entries ADD COLUMN tail_number TEXT`,
+	`ALTER TABLE pay_entries ADD COLUMN aircraft_type TEXT`,
+	`ALTER TABLE pay_entries ADD COLUMN departure TEXT`,
+	`ALTER TABLE pay_entries ADD COLUMN arrival TEXT`,
+	`ALTER TABLE pay_entries ADD COLUMN day_landings INTEGER`,
+	`ALTER TABLE pay_entries ADD COLUMN night_landings INTEGER`,
+	`ALTER TABLE pay_entries ADD COLUMN night_hours DECIMAL(4,2)`,
+	`ALTER TABLE pay_entries ADD COLUMN ifr_hours DECIMAL(4,2)`,
+	`ALTER TABLE pay_entries ADD COLUMN xc_hours DECIMAL(4,2)`,
+	`ALTER TABLE pay_entries ADD COLUMN hobbs_start DECIMAL(8,1)`,
+	`ALTER TABLE pay_entries ADD COLUMN hobbs_end DECIMAL(8,1)`,
+	`ALTER TABLE pay_entries ADD COLUMN tach_start DECIMAL(8,1)`,
+	`ALTER TABLE pay_entries ADD COLUMN tach_end DECIMAL(8,1)`,
+}
+
+func (database *Database) migrate() error {
+	for _, statement := range migrations {
+		_, err := database.Exec(statement)
+		if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+			return fmt.Errorf("migration failed: %w", err)
+		}
+	}
+
+	if err := database.linkEntryCustomers(); err != nil {
+		return fmt.Errorf("customer migration failed: %w", err)
+	}
+	return nil
+}
+
 func (database *Database) CheckHealth() Response {
 	if err := database.Ping(); err != nil {
 		return Response{
@@ -68,57 +120,52 @@ func (database *Database) CheckHealth() Response {
 	}
 }
 
+// UpdatePaycheck records the actual gross and net paid for a pay period
+// and, when given, corrects its pay date. Period status is left alone.
 func (database *Database) UpdatePaycheck(check Paycheck) Response {
+	affected, err := writePaycheck(database, check)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to update pay period ID=%d: %s", check.ID, err),
+		}
+	}
+	if affected == 0 {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to find pay period ID=%d", check.ID),
+		}
+	}
+
+	log.Printf("Updated paycheck for pay period ID: %d\n", check.ID)
 	return Response{
 		Status:  "OK",
-		Message: "Paycheck updated (TODO)",
+		Message: "Updated paycheck:",
+		Data:    json.RawMessage(fmt.Sprintf(`{"period_id": %d}`, check.ID)),
 	}
 }
 
-func (database *Database) FetchEntries(beginDate string, endDate string) ([]Entry, error) {
-	query := `
-        SELECT id, type, date, time, flight_hours, ground_hours, sim_hours, 
-               admin_hours, customer, notes, ride_count, meeting
-        FROM pay_entries 
-    `
-
-	var args []interface{}
-
-	if beginDate != "all" && endDate != "all" {
-		beginDate = strings.Split(beginDate, "T")[0]
-		endDate = strings.Split(endDate, "T")[0]
-		query += "WHERE date BETWEEN ? AND ?"
-		args = []interface{}{beginDate, endDate}
-	}
-
-	query += "ORDER BY date DESC, time DESC"
-
-	rows, err := database.Query(query, args...)
+// writePaycheck stores a paycheck through exec and returns how many pay
+// periods it matched.
+func writePaycheck(exec execer, check Paycheck) (int64, error) {
+	result, err := exec.Exec(`
+		UPDATE pay_periods
+		SET pay_date = COALESCE(?, pay_date), actual_pay_gross = ?, actual_pay_net = ?,
+		    last_updated = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, nullIfEmpty(check.PayDate), nilCheck(check.GrossActual), nilCheck(check.NetActual), check.ID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch entries: %w", err)
-	}
-	defer rows.Close()
-
-	var collectedEntries []Entry
-	for rows.Next() {
-		var entry Entry
-		err := rows.Scan(
-			&entry.ID, &entry.Type, &entry.Date, &entry.Time,
-			&entry.FlightHours, &entry.GroundHours, &entry.SimHours,
-			&entry.AdminHours, &entry.Customer, &entry.Notes,
-			&entry.RideCount, &entry.Meeting,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan entry: %w", err)
-		}
-		collectedEntries = append(collectedEntries, entry)
-	}
-
-	if collectedEntries == nil {
-		collectedEntries = []Entry{}
+		return 0, err
 	}
+	return result.RowsAffected()
+}
 
-	return collectedEntries, nil
+func (database *Database) FetchEntries(beginDate string, endDate string) ([]Entry, error) {
+	entries, _, err := database.FetchFilteredEntries(EntryFilter{
+		BeginDate: beginDate,
+		EndDate:   endDate,
+	})
+	return entries, err
 }
 
 func (database *Database) Close() error {
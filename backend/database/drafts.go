@@ -0,0 +1,210 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+const draftColumns = `id, date, COALESCE(time, ''), type, COALESCE(hours, 0), customer, notes,
+	meeting, source_uid, status, entry_id, COALESCE(created_at, '')`
+
+func scanDraft(row rowScanner) (DraftEntry, error) {
+	var draft DraftEntry
+	err := row.Scan(&draft.ID, &draft.Date, &draft.Time, &draft.Type, &draft.Hours,
+		&draft.Customer, &draft.Notes, &draft.Meeting, &draft.SourceUID, &draft.Status,
+		&draft.EntryID, &draft.CreatedAt)
+	draft.Date = strings.Split(draft.Date, "T")[0]
+	return draft, err
+}
+
+// CreateDrafts stores drafts from an import. Drafts whose source UID was
+// imported before, whatever became of them, are skipped and counted.
+func (database *Database) CreateDrafts(drafts []DraftEntry) ([]DraftEntry, int, error) {
+	created := []DraftEntry{}
+	skipped := 0
+	for _, draft := range drafts {
+		result, err := database.Exec(`
+			INSERT OR IGNORE INTO draft_entries (date, time, type, hours, customer, notes, meeting, source_uid)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, draft.Date, nullIfEmpty(draft.Time), draft.Type, draft.Hours,
+			nilCheck(draft.Customer), nilCheck(draft.Notes), draft.Meeting, draft.SourceUID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to create draft: %w", err)
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			skipped++
+			continue
+		}
+		newID, err := result.LastInsertId()
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to get new draft ID: %w", err)
+		}
+		draft.ID = int(newID)
+		draft.Status = "pending"
+		created = append(created, draft)
+	}
+
+	log.Printf("Created %d drafts, skipped %d already imported\n", len(created), skipped)
+	return created, skipped, nil
+}
+
+// GetDrafts lists drafts by date, optionally only those with a status.
+func (database *Database) GetDrafts(status string) ([]DraftEntry, error) {
+	query := "SELECT " + draftColumns + " FROM draft_entries"
+	var args []interface{}
+	if status != "" {
+		query += " WHERE status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY date, time"
+
+	rows, err := database.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get drafts: %w", err)
+	}
+	defer rows.Close()
+
+	drafts := []DraftEntry{}
+	for rows.Next() {
+		draft, err := scanDraft(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan draft: %w", err)
+		}
+		drafts = append(drafts, draft)
+	}
+	return drafts, rows.Err()
+}
+
+// ConfirmDraft turns a pending draft into an entry. Fields set on entry
+// override the draft; the draft's hours go under its type when none are given.
+// meeting, when given, overrides the draft's meeting flag either way.
+func (database *Database) ConfirmDraft(id int, entry Entry, meeting *bool) Response {
+	draft, err := scanDraft(database.QueryRow("SELECT "+draftColumns+" FROM draft_entries WHERE id = ?", id))
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to find draft ID=%d", id),
+		}
+	}
+	if draft.Status != "pending" {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Draft ID=%d is already %s", id, draft.Status),
+		}
+	}
+
+	if entry.Date == "" {
+		entry.Date = draft.Date
+	}
+	if entry.Time == "" {
+		entry.Time = draft.Time
+	}
+	if entry.Type == "" {
+		entry.Type = draft.Type
+	}
+	if entry.Customer == nil {
+		entry.Customer = draft.Customer
+	}
+	if entry.Notes == nil {
+		entry.Notes = draft.Notes
+	}
+	entry.Meeting = draft.Meeting
+	if meeting != nil {
+		entry.Meeting = *meeting
+	}
+	if entry.FlightHours == nil && entry.GroundHours == nil && entry.SimHours == nil &&
+		entry.AdminHours == nil && draft.Hours > 0 {
+		hours := draft.Hours
+		switch entry.Type {
+		case "flight":
+			entry.FlightHours = &hours
+		case "ground":
+			entry.GroundHours = &hours
+		case "sim":
+			entry.SimHours = &hours
+		default:
+			entry.AdminHours = &hours
+		}
+	}
+
+	periodID, msg := database.prepareNewEntry(&entry)
+	if msg != "" {
+		return Response{
+			Status:  "ERROR",
+			Message: msg,
+		}
+	}
+
+	tx, err := database.Begin()
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to confirm draft ID=%d: %s", id, err),
+		}
+	}
+	defer tx.Rollback()
+
+	entryID, err := insertEntry(tx, periodID, entry)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: err.Error(),
+		}
+	}
+	result, err := tx.Exec("UPDATE draft_entries SET status = 'confirmed', entry_id = ? WHERE id = ? AND status = 'pending'",
+		entryID, id)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to confirm draft ID=%d: %s", id, err),
+		}
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Draft ID=%d is no longer pending", id),
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to confirm draft ID=%d: %s", id, err),
+		}
+	}
+	database.entryAdded(periodID, entry.Date)
+
+	log.Printf("Confirmed draft ID: %d as entry ID: %d\n", id, entryID)
+	return Response{
+		Status:  "OK",
+		Message: "Draft confirmed:",
+		Data:    json.RawMessage(fmt.Sprintf(`{"draft_id": %d, "entry_id": %d}`, id, entryID)),
+	}
+}
+
+// DiscardDraft drops a pending draft. The row is kept so the same calendar
+// event is not imported again.
+func (database *Database) DiscardDraft(id int) Response {
+	result, err := database.Exec(
+		"UPDATE draft_entries SET status = 'discarded' WHERE id = ? AND status = 'pending'", id)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to discard draft ID=%d: %s", id, err),
+		}
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to find pending draft ID=%d", id),
+		}
+	}
+
+	log.Printf("Discarded draft ID: %d\n", id)
+	return Response{
+		Status:  "OK",
+		Message: "Draft discarded:",
+		Data:    json.RawMessage(fmt.Sprintf(`{"draft_id": %d}`, id)),
+	}
+}
@@ -1,31 +1,79 @@
 package database
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
 )
 
 const newEntrySQL = `
 INSERT INTO pay_entries (
     pay_period_id, type, date, time, 
     flight_hours, ground_hours, sim_hours, admin_hours,
-    customer, notes, ride_count, meeting
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    customer, customer_id, notes, ride_count, meeting,
+    ` + logbookColumns + `
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 `
 
+// execer is the database or a transaction, for writes that may be part of
+// a larger change.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
 func (database *Database) NewEntry(entry Entry) Response {
-	payPeriod, err := database.GetCurrentPayPeriod(entry.Date)
+	periodID, msg := database.prepareNewEntry(&entry)
+	if msg != "" {
+		return Response{
+			Status:  "ERROR",
+			Message: msg,
+		}
+	}
+
+	newID, err := insertEntry(database, periodID, entry)
 	if err != nil {
-		fmt.Printf("Error getting pay period: %v\n", err)
+		fmt.Printf("Error! %v\n", err)
 		return Response{
 			Status:  "ERROR",
-			Message: fmt.Sprintf("error getting pay period: %v", err),
+			Message: err.Error(),
 		}
 	}
 
-	result, err := database.Exec(newEntrySQL,
-		payPeriod.ID,
+	database.entryAdded(periodID, entry.Date)
+	log.Printf("Created entry ID: %d\n", newID)
+	return Response{
+		Status:  "OK",
+		Message: "New entry created:",
+		Data:    json.RawMessage(fmt.Sprintf(`{"entry_id": %d}`, newID)),
+	}
+}
+
+// prepareNewEntry validates an entry, resolves its customer and finds its
+// pay period, returning the period ID or an error message.
+func (database *Database) prepareNewEntry(entry *Entry) (int, string) {
+	if err := entry.prepareLogbook(); err != nil {
+		return 0, fmt.Sprintf("invalid logbook fields: %v", err)
+	}
+
+	payPeriod, err := database.GetCurrentPayPeriod(entry.Date)
+	if err != nil {
+		fmt.Printf("Error getting pay period: %v\n", err)
+		return 0, fmt.Sprintf("error getting pay period: %v", err)
+	}
+
+	entry.CustomerID, entry.Customer, err = database.ResolveCustomer(entry.Customer)
+	if err != nil {
+		return 0, fmt.Sprintf("error resolving customer: %v", err)
+	}
+	return payPeriod.ID, ""
+}
+
+// insertEntry writes a prepared entry through exec and returns its ID.
+func insertEntry(exec execer, periodID int, entry Entry) (int64, error) {
+	values := []interface{}{
+		periodID,
 		entry.Type,
 		entry.Date,
 		entry.Time,
@@ -34,43 +82,40 @@ func (database *Database) NewEntry(entry Entry) Response {
 		nilCheck(entry.SimHours),
 		nilCheck(entry.AdminHours),
 		nilCheck(entry.Customer),
+		nilCheck(entry.CustomerID),
 		nilCheck(entry.Notes),
 		nilCheck(entry.RideCount),
 		entry.Meeting,
-	)
+	}
+	result, err := exec.Exec(newEntrySQL, append(values, entry.logbookValues()...)...)
 	if err != nil {
-		fmt.Printf("Error! %v\n", err)
-		return Response{
-			Status:  "ERROR",
-			Message: fmt.Sprintf("error creating entry: %v", err),
-		}
+		return 0, fmt.Errorf("error creating entry: %v", err)
 	}
 
 	newID, err := result.LastInsertId()
 	if err != nil {
-		fmt.Printf("Error, ID unknown! %v\n", err)
-		return Response{
-			Status:  "ERROR",
-			Message: fmt.Sprintf("created entry, ID error: %v", err),
-		}
+		return 0, fmt.Errorf("created entry, ID error: %v", err)
 	}
+	return newID, nil
+}
 
-	err = database.UpdatePayPeriodTotals(payPeriod.ID)
+// entryAdded refreshes the stored totals a new entry changes.
+func (database *Database) entryAdded(periodID int, date string) {
+	err := database.UpdatePayPeriodTotals(periodID)
 	if err != nil {
 		log.Printf("Warning: failed to update pay period totals: %v", err)
 	}
-
-	log.Printf("Created entry ID: %d\n", newID)
-	return Response{
-		Status:  "OK",
-		Message: "New entry created:",
-		Data:    json.RawMessage(fmt.Sprintf(`{"entry_id": %d}`, newID)),
-	}
+	database.updateMonthlyStatsFor(date)
 }
 
+// UpdateEntry saves an edited entry. Logbook fields left out of the update
+// keep their stored values on flight entries.
 func (database *Database) UpdateEntry(entry Entry) Response {
 	var currentPayPeriodID int
-	err := database.QueryRow("SELECT pay_period_id FROM pay_entries WHERE id = ?", entry.ID).Scan(&currentPayPeriodID)
+	var currentDate string
+	var stored Entry
+	err := database.QueryRow("SELECT pay_period_id, date, "+logbookColumns+" FROM pay_entries WHERE id = ?", entry.ID).
+		Scan(append([]interface{}{&currentPayPeriodID, &currentDate}, stored.logbookTargets()...)...)
 	if err != nil {
 		return Response{
 			Status:  "ERROR",
@@ -78,6 +123,16 @@ func (database *Database) UpdateEntry(entry Entry) Response {
 		}
 	}
 
+	if entry.Type == "flight" {
+		entry.keepLogbook(stored)
+	}
+	if err := entry.prepareLogbook(); err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("invalid logbook fields: %v", err),
+		}
+	}
+
 	payPeriod, err := database.GetCurrentPayPeriod(entry.Date)
 	if err != nil {
 		return Response{
@@ -86,13 +141,26 @@ func (database *Database) UpdateEntry(entry Entry) Response {
 		}
 	}
 
+	entry.CustomerID, entry.Customer, err = database.ResolveCustomer(entry.Customer)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("error resolving customer: %v", err),
+		}
+	}
+
 	updateQuery := `
 UPDATE pay_entries SET pay_period_id = ?, date = ?, time = ?, flight_hours = ?,
-ground_hours = ?, sim_hours = ?, admin_hours = ?, customer = ?,
-notes = ?, ride_count = ?, meeting = ? WHERE id = ?`
-	_, err = database.Exec(updateQuery, payPeriod.ID, entry.Date, entry.Time, entry.FlightHours,
-		entry.GroundHours, entry.SimHours, entry.AdminHours, entry.Customer, entry.Notes,
-		entry.RideCount, entry.Meeting, entry.ID)
+ground_hours = ?, sim_hours = ?, admin_hours = ?, customer = ?, customer_id = ?,
+notes = ?, ride_count = ?, meeting = ?, tail_number = ?, aircraft_type = ?,
+departure = ?, arrival = ?, day_landings = ?, night_landings = ?, night_hours = ?,
+ifr_hours = ?, xc_hours = ?, hobbs_start = ?, hobbs_end = ?, tach_start = ?,
+tach_end = ? WHERE id = ?`
+	values := []interface{}{payPeriod.ID, entry.Date, entry.Time, entry.FlightHours,
+		entry.GroundHours, entry.SimHours, entry.AdminHours, entry.Customer, entry.CustomerID,
+		entry.Notes, entry.RideCount, entry.Meeting}
+	values = append(values, entry.logbookValues()...)
+	_, err = database.Exec(updateQuery, append(values, entry.ID)...)
 	if err != nil {
 		return Response{
 			Status:  "ERROR",
@@ -114,6 +182,11 @@ notes = ?, ride_count = ?, meeting = ? WHERE id = ?`
 		}
 	}
 
+	database.updateMonthlyStatsFor(entry.Date)
+	if strings.Split(currentDate, "T")[0] != entry.Date {
+		database.updateMonthlyStatsFor(currentDate)
+	}
+
 	return Response{
 		Status:  "OK",
 		Message: "Updated entry:",
@@ -123,7 +196,8 @@ notes = ?, ride_count = ?, meeting = ? WHERE id = ?`
 
 func (database *Database) DeleteEntry(id string) Response {
 	var payPeriodID int
-	err := database.QueryRow("SELECT pay_period_id FROM pay_entries WHERE id = ?", id).Scan(&payPeriodID)
+	var date string
+	err := database.QueryRow("SELECT pay_period_id, date FROM pay_entries WHERE id = ?", id).Scan(&payPeriodID, &date)
 	if err != nil {
 		msg := fmt.Sprintf("Unable to find entry ID=%s: %s", id, err)
 		log.Printf("Delete error: %s\n", msg)
@@ -145,10 +219,15 @@ func (database *Database) DeleteEntry(id string) Response {
 	}
 
 	log.Printf("Deleted entry ID: %s\n", id)
+	_, err = database.Exec("UPDATE scheduled_lessons SET status = 'scheduled', entry_id = NULL WHERE entry_id = ?", id)
+	if err != nil {
+		log.Printf("Warning: failed to reopen lesson for deleted entry: %v", err)
+	}
 	err = database.UpdatePayPeriodTotals(payPeriodID)
 	if err != nil {
 		log.Printf("Warning: failed to update pay period totals after deletion: %v", err)
 	}
+	database.updateMonthlyStatsFor(date)
 	return Response{
 		Status:  "OK",
 		Message: "Entry deleted:",
@@ -176,3 +255,128 @@ func (database *Database) GetCheckEntries(checkID int) ([]Entry, error) {
 	}
 	return entries, nil
 }
+
+var entrySortColumns = map[string]string{
+	"date":         "date",
+	"time":         "time",
+	"type":         "type",
+	"customer":     "customer",
+	"flight_hours": "flight_hours",
+	"ground_hours": "ground_hours",
+	"sim_hours":    "sim_hours",
+	"admin_hours":  "admin_hours",
+	"ride_count":   "ride_count",
+	"tail_number":  "tail_number",
+	"created_at":   "created_at",
+}
+
+// likeEscaper makes user text match literally inside a LIKE pattern with
+// ESCAPE '\'.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// FetchFilteredEntries returns the entries matching filter along with the
+// total number of matches before limit/offset are applied.
+func (database *Database) FetchFilteredEntries(filter EntryFilter) ([]Entry, int, error) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.BeginDate != "" && filter.BeginDate != "all" {
+		conditions = append(conditions, "date >= ?")
+		args = append(args, strings.Split(filter.BeginDate, "T")[0])
+	}
+	if filter.EndDate != "" && filter.EndDate != "all" {
+		conditions = append(conditions, "date <= ?")
+		args = append(args, strings.Split(filter.EndDate, "T")[0])
+	}
+	if len(filter.Types) > 0 {
+		placeholders := make([]string, len(filter.Types))
+		for i, entryType := range filter.Types {
+			placeholders[i] = "?"
+			args = append(args, entryType)
+		}
+		conditions = append(conditions, "type IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if filter.Customer != "" {
+		conditions = append(conditions, `(LOWER(customer) = LOWER(?) OR customer_id IN (
+			SELECT customer_id FROM customer_aliases WHERE alias = ?))`)
+		args = append(args, filter.Customer, filter.Customer)
+	}
+	if filter.CustomerID != 0 {
+		conditions = append(conditions, "customer_id = ?")
+		args = append(args, filter.CustomerID)
+	}
+	if filter.Tail != "" {
+		conditions = append(conditions, "UPPER(tail_number) = UPPER(?)")
+		args = append(args, filter.Tail)
+	}
+	if filter.Meeting != nil {
+		conditions = append(conditions, "meeting = ?")
+		args = append(args, *filter.Meeting)
+	}
+	if filter.Search != "" {
+		conditions = append(conditions, `notes LIKE '%' || ? || '%' ESCAPE '\'`)
+		args = append(args, likeEscaper.Replace(filter.Search))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	err := database.QueryRow("SELECT COUNT(*) FROM pay_entries"+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count entries: %w", err)
+	}
+
+	orderBy := "date DESC, time DESC"
+	if filter.SortBy != "" {
+		column, ok := entrySortColumns[filter.SortBy]
+		if !ok {
+			return nil, 0, fmt.Errorf("invalid sort key: %s", filter.SortBy)
+		}
+		direction := "ASC"
+		if filter.SortDesc {
+			direction = "DESC"
+		}
+		orderBy = fmt.Sprintf("%s %s, date DESC, time DESC", column, direction)
+	}
+
+	query := `
+        SELECT id, type, date, time, flight_hours, ground_hours, sim_hours,
+               admin_hours, customer, customer_id, notes, ride_count, meeting,
+               ` + logbookColumns + `
+        FROM pay_entries` + where + " ORDER BY " + orderBy + ", id DESC"
+
+	if filter.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, filter.Limit, filter.Offset)
+	} else if filter.Offset > 0 {
+		query += " LIMIT -1 OFFSET ?"
+		args = append(args, filter.Offset)
+	}
+
+	rows, err := database.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch entries: %w", err)
+	}
+	defer rows.Close()
+
+	collectedEntries := []Entry{}
+	for rows.Next() {
+		var entry Entry
+		targets := []interface{}{
+			&entry.ID, &entry.Type, &entry.Date, &entry.Time,
+			&entry.FlightHours, &entry.GroundHours, &entry.SimHours,
+			&entry.AdminHours, &entry.Customer, &entry.CustomerID, &entry.Notes,
+			&entry.RideCount, &entry.Meeting,
+		}
+		err := rows.Scan(append(targets, entry.logbookTargets()...)...)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan entry: %w", err)
+		}
+		collectedEntries = append(collectedEntries, entry)
+	}
+
+	return collectedEntries, total, nil
+}
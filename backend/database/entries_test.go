@@ -0,0 +1,40 @@
+package database
+
+import "testing"
+
+func textOf(value string) *string {
+	return &value
+}
+
+func TestFetchFilteredEntriesSearchIsLiteral(t *testing.T) {
+	database := openTestDB(t)
+
+	for _, notes := range []string{"50% done", "stall practice", `C:\logbook`, "steep turns"} {
+		entry := Entry{Type: "flight", Date: "2025-03-03", Time: "08:00", FlightHours: hoursOf(1), Notes: textOf(notes)}
+		if response := database.NewEntry(entry); response.Status != "OK" {
+			t.Fatal(response.Message)
+		}
+	}
+
+	tests := []struct {
+		search string
+		want   int
+	}{
+		{"%", 1},
+		{"_", 0},
+		{`\`, 1},
+		{"50%", 1},
+		{"l_p", 0}, // not a wildcard for "stall practice"
+		{"turns", 1},
+		{"s", 2},
+	}
+	for _, tt := range tests {
+		_, total, err := database.FetchFilteredEntries(EntryFilter{Search: tt.search})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total != tt.want {
+			t.Errorf("search %q matched %d entries, want %d", tt.search, total, tt.want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+var goalScopes = map[string]bool{"period": true, "month": true, "year": true}
+var goalMetrics = map[string]bool{"gross": true, "hours": true}
+
+func (db *Database) CreateGoal(goal Goal) Response {
+	if !goalScopes[goal.Scope] {
+		return Response{
+			Status:  "ERROR",
+			Message: "Invalid goal scope. Use: period, month, or year",
+		}
+	}
+	if !goalMetrics[goal.Metric] {
+		return Response{
+			Status:  "ERROR",
+			Message: "Invalid goal metric. Use: gross or hours",
+		}
+	}
+	if goal.Target <= 0 {
+		return Response{
+			Status:  "ERROR",
+			Message: "Goal target must be greater than zero",
+		}
+	}
+	if goal.EffectiveDate == "" {
+		goal.EffectiveDate = time.Now().In(time.Local).Format("2006-01-02")
+	}
+	if _, err := time.Parse("2006-01-02", goal.EffectiveDate); err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Invalid effective date '%s', expected YYYY-MM-DD", goal.EffectiveDate),
+		}
+	}
+
+	result, err := db.Exec(
+		"INSERT INTO income_goals (scope, metric, target, effective_date) VALUES (?, ?, ?, ?)",
+		goal.Scope, goal.Metric, goal.Target, goal.EffectiveDate,
+	)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("error creating goal: %v", err),
+		}
+	}
+	newID, err := result.LastInsertId()
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("created goal, ID error: %v", err),
+		}
+	}
+
+	log.Printf("Created goal ID: %d\n", newID)
+	return Response{
+		Status:  "OK",
+		Message: "New goal created:",
+		Data:    json.RawMessage(fmt.Sprintf(`{"goal_id": %d}`, newID)),
+	}
+}
+
+// GetGoalHistory lists every goal ever set, newest first, optionally for a
+// single scope.
+func (db *Database) GetGoalHistory(scope string) ([]Goal, error) {
+	query := `
+		SELECT id, scope, metric, target, effective_date, created_at
+		FROM income_goals
+	`
+	var args []interface{}
+	if scope != "" {
+		query += " WHERE scope = ?"
+		args = append(args, scope)
+	}
+	query += " ORDER BY effective_date DESC, id DESC"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goals: %v", err)
+	}
+	defer rows.Close()
+
+	goals := []Goal{}
+	for rows.Next() {
+		var goal Goal
+		err = rows.Scan(&goal.ID, &goal.Scope, &goal.Metric, &goal.Target,
+			&goal.EffectiveDate, &goal.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan goal: %v", err)
+		}
+		goal.EffectiveDate = strings.Split(goal.EffectiveDate, "T")[0]
+		goals = append(goals, goal)
+	}
+	return goals, nil
+}
+
+// GetActiveGoal returns the goal in effect on date for scope and metric, or
+// nil when none has been set.
+func (db *Database) GetActiveGoal(scope, metric, date string) (*Goal, error) {
+	goals, err := db.GetGoalHistory(scope)
+	if err != nil {
+		return nil, err
+	}
+	date = strings.Split(date, "T")[0]
+	for _, goal := range goals {
+		if goal.Metric == metric && goal.EffectiveDate <= date {
+			return &goal, nil
+		}
+	}
+	return nil, nil
+}
+
+// GoalRange returns the period, month or year containing date.
+func GoalRange(scope string, date time.Time) (time.Time, time.Time) {
+	switch scope {
+	case "period":
+		return PeriodBounds(date)
+	case "month":
+		start := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, -1)
+	default:
+		start := time.Date(date.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(1, 0, -1)
+	}
+}
+
+// GetGoalProgress evaluates every goal in effect on date against the
+// period, month or year containing it. Gross shortfalls are converted to
+// hours at the CFI rate in effect on date.
+func (db *Database) GetGoalProgress(date string) ([]GoalProgress, error) {
+	date = strings.Split(date, "T")[0]
+	asOf, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date format: %v", err)
+	}
+
+	rates, err := db.GetCurrentRates(date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rates: %v", err)
+	}
+
+	progress := []GoalProgress{}
+	for _, scope := range []string{"period", "month", "year"} {
+		for _, metric := range []string{"gross", "hours"} {
+			goal, err := db.GetActiveGoal(scope, metric, date)
+			if err != nil {
+				return nil, err
+			}
+			if goal == nil {
+				continue
+			}
+
+			start, end := GoalRange(scope, asOf)
+			projection, err := db.ProjectRange(scope, start.Format("2006-01-02"),
+				end.Format("2006-01-02"), date, 0, nil)
+			if err != nil {
+				return nil, err
+			}
+
+			item := GoalProgress{
+				Goal:      *goal,
+				BeginDate: projection.BeginDate,
+				EndDate:   projection.EndDate,
+			}
+			if metric == "gross" {
+				item.Achieved = projection.EarnedGross
+				item.Projected = projection.ProjectedGross
+			} else {
+				item.Achieved = projection.EarnedHours
+				item.Projected = projection.ProjectedHours
+			}
+
+			if item.Achieved < goal.Target {
+				item.Remaining = goal.Target - item.Achieved
+			}
+			item.RemainingHoursNeeded = item.Remaining
+			if metric == "gross" {
+				item.RemainingHoursNeeded = 0
+				if rates.CFIRate > 0 {
+					item.RemainingHoursNeeded = item.Remaining / rates.CFIRate
+				}
+			}
+			item.PercentComplete = item.Achieved / goal.Target * 100
+			item.OnPace = item.Projected >= goal.Target
+
+			progress = append(progress, item)
+		}
+	}
+	return progress, nil
+}
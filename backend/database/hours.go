@@ -0,0 +1,174 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// durationFields are the Entry fields that accept "H:MM" as well as
+// decimal hours.
+var durationFields = []string{
+	"flight_hours", "ground_hours", "sim_hours", "admin_hours",
+	"night_hours", "ifr_hours", "xc_hours",
+}
+
+// roundingCategories are the pay categories a rounding rule can name.
+var roundingCategories = map[string]bool{
+	"flight": true, "ground": true, "sim": true, "admin": true, "misc": true,
+}
+
+// ParseDuration reads a duration as "H:MM" (e.g. "1:20") or decimal hours
+// ("1.5") and returns exact hours.
+func ParseDuration(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+	if hoursPart, minutesPart, ok := strings.Cut(value, ":"); ok {
+		// digits only: Atoi would take "-0" as 0 and "+5" as 5
+		if !isDigits(hoursPart) || !isDigits(minutesPart) || len(minutesPart) != 2 {
+			return 0, fmt.Errorf("invalid duration '%s', expected H:MM", value)
+		}
+		hours, err := strconv.Atoi(hoursPart)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration '%s', expected H:MM", value)
+		}
+		minutes, _ := strconv.Atoi(minutesPart)
+		if minutes > 59 {
+			return 0, fmt.Errorf("invalid duration '%s', expected H:MM", value)
+		}
+		return float64(hours) + float64(minutes)/60, nil
+	}
+	hours, err := strconv.ParseFloat(value, 64)
+	if err != nil || hours < 0 {
+		return 0, fmt.Errorf("invalid duration '%s'", value)
+	}
+	return hours, nil
+}
+
+func isDigits(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// DurationError reports an hour field that is neither H:MM nor a number.
+type DurationError struct {
+	Field string
+	Err   error
+}
+
+func (err *DurationError) Error() string {
+	return fmt.Sprintf("%s: %v", err.Field, err.Err)
+}
+
+func (err *DurationError) Unwrap() error {
+	return err.Err
+}
+
+// UnmarshalJSON accepts durations given as "H:MM" strings for the hour
+// fields, storing them as exact decimal hours. They are written to SQLite
+// as REAL, so minutes that aren't a whole hundredth of an hour (1:20 is
+// 1.3333...) carry float precision rather than being truncated. Fields
+// sent as null are noted so an update can tell them from omitted ones.
+func (entry *Entry) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	cleared := map[string]bool{}
+	for name, raw := range fields {
+		if string(raw) == "null" {
+			cleared[name] = true
+		}
+	}
+	for _, name := range durationFields {
+		var text string
+		if raw, ok := fields[name]; !ok || json.Unmarshal(raw, &text) != nil {
+			continue
+		}
+		if text == "" {
+			delete(fields, name)
+			continue
+		}
+		hours, err := ParseDuration(text)
+		if err != nil {
+			return &DurationError{Field: name, Err: err}
+		}
+		fields[name], _ = json.Marshal(hours)
+	}
+
+	normalized, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	type plain Entry
+	if err := json.Unmarshal(normalized, (*plain)(entry)); err != nil {
+		return err
+	}
+	entry.cleared = cleared
+	return nil
+}
+
+// Validate checks every rule names a pay category, a positive increment
+// and a known mode.
+func (rounding Rounding) Validate() error {
+	for category, rule := range rounding {
+		if !roundingCategories[category] {
+			return fmt.Errorf("invalid rounding category '%s': use flight, ground, sim, admin or misc", category)
+		}
+		if rule.Increment <= 0 {
+			return fmt.Errorf("rounding increment for %s must be a positive number of minutes", category)
+		}
+		switch rule.Mode {
+		case "", "nearest", "up", "down":
+		default:
+			return fmt.Errorf("invalid rounding mode '%s' for %s: use nearest, up or down", rule.Mode, category)
+		}
+	}
+	return nil
+}
+
+// Apply rounds hours logged in category to the category's increment.
+// Categories without a rule are left exact.
+func (rounding Rounding) Apply(category string, hours float64) float64 {
+	rule, ok := rounding[category]
+	if !ok || rule.Increment <= 0 || hours == 0 {
+		return hours
+	}
+	// work in minutes, nudged so float error (0.1 h = 6.000000000000001
+	// min) can't move an exact multiple a step
+	steps := hours * 60 / float64(rule.Increment)
+	switch rule.Mode {
+	case "up":
+		steps = math.Ceil(steps - 1e-9)
+	case "down":
+		steps = math.Floor(steps + 1e-9)
+	default:
+		steps = math.Round(steps)
+	}
+	return steps * float64(rule.Increment) / 60
+}
+
+// entryHours is an entry's flight, ground, sim and admin hours after
+// rounding. A misc entry's hours are all paid as misc, so they are rounded
+// by the misc rule.
+func (rounding Rounding) entryHours(entry Entry) (float64, float64, float64, float64) {
+	categories := [4]string{"flight", "ground", "sim", "admin"}
+	if entry.Type == "misc" {
+		categories = [4]string{"misc", "misc", "misc", "misc"}
+	}
+	return rounding.Apply(categories[0], nilFloat(entry.FlightHours)),
+		rounding.Apply(categories[1], nilFloat(entry.GroundHours)),
+		rounding.Apply(categories[2], nilFloat(entry.SimHours)),
+		rounding.Apply(categories[3], nilFloat(entry.AdminHours))
+}
@@ -0,0 +1,146 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func closeTo(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    float64
+		wantErr bool
+	}{
+		{"1:20", 1 + 20.0/60, false},
+		{"0:06", 0.1, false},
+		{"2:00", 2, false},
+		{" 1:30 ", 1.5, false},
+		{"10:59", 10 + 59.0/60, false},
+		{"1.5", 1.5, false},
+		{"0", 0, false},
+		{"1:5", 0, true},
+		{"1:60", 0, true},
+		{"-1:00", 0, true},
+		{"-0:30", 0, true},
+		{"+1:00", 0, true},
+		{"1:+5", 0, true},
+		{"1:-0", 0, true},
+		{":30", 0, true},
+		{"1:xx", 0, true},
+		{"-0.5", 0, true},
+		{"abc", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseDuration(%q) = %v, want an error", tt.value, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseDuration(%q): %v", tt.value, err)
+			continue
+		}
+		if !closeTo(got, tt.want) {
+			t.Errorf("ParseDuration(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestEntryUnmarshalDurations(t *testing.T) {
+	var entry Entry
+	err := json.Unmarshal([]byte(`{"type":"flight","flight_hours":"1:20","ground_hours":0.5,"sim_hours":""}`), &entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.FlightHours == nil || !closeTo(*entry.FlightHours, 1+20.0/60) {
+		t.Errorf("flight_hours = %v, want 1:20 as hours", entry.FlightHours)
+	}
+	if entry.GroundHours == nil || *entry.GroundHours != 0.5 {
+		t.Errorf("ground_hours = %v, want 0.5", entry.GroundHours)
+	}
+	if entry.SimHours != nil {
+		t.Errorf("empty sim_hours = %v, want nil", *entry.SimHours)
+	}
+
+	err = json.Unmarshal([]byte(`{"admin_hours":"1:75"}`), &entry)
+	var durationErr *DurationError
+	if !errors.As(err, &durationErr) || durationErr.Field != "admin_hours" {
+		t.Errorf("bad admin_hours gave %v, want a DurationError for admin_hours", err)
+	}
+}
+
+func TestRoundingApply(t *testing.T) {
+	rounding := Rounding{
+		"flight": {Increment: 6},
+		"ground": {Increment: 15, Mode: "up"},
+		"admin":  {Increment: 15, Mode: "down"},
+	}
+
+	tests := []struct {
+		category string
+		hours    float64
+		want     float64
+	}{
+		{"flight", 1 + 20.0/60, 1.3},      // 80 min to the nearest 6
+		{"flight", 1 + 21.0/60, 1.4},      // 81 min rounds up at the half
+		{"flight", 0.3, 0.3},              // exact tenths are left alone
+		{"ground", 1 + 1.0/60, 1.25},      // up to the next quarter
+		{"ground", 1.25, 1.25},            // an exact quarter doesn't move
+		{"admin", 1 + 14.0/60, 1},         // down to the quarter
+		{"admin", 0.75, 0.75},             // an exact quarter doesn't move
+		{"sim", 1 + 20.0/60, 1 + 20.0/60}, // no rule, exact
+		{"flight", 0, 0},
+	}
+
+	for _, tt := range tests {
+		got := rounding.Apply(tt.category, tt.hours)
+		if !closeTo(got, tt.want) {
+			t.Errorf("Apply(%s, %v) = %v, want %v", tt.category, tt.hours, got, tt.want)
+		}
+	}
+}
+
+func TestRoundingValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		rounding Rounding
+		wantErr  bool
+	}{
+		{"empty", Rounding{}, false},
+		{"valid", Rounding{"flight": {Increment: 6, Mode: "nearest"}, "misc": {Increment: 15}}, false},
+		{"unknown category", Rounding{"rides": {Increment: 6}}, true},
+		{"zero increment", Rounding{"flight": {Increment: 0}}, true},
+		{"unknown mode", Rounding{"flight": {Increment: 6, Mode: "sideways"}}, true},
+	}
+
+	for _, tt := range tests {
+		err := tt.rounding.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestEntryHoursMiscRule(t *testing.T) {
+	hours := 1 + 20.0/60
+	rounding := Rounding{"misc": {Increment: 15}, "admin": {Increment: 6}}
+
+	_, _, _, admin := rounding.entryHours(Entry{Type: "misc", AdminHours: &hours})
+	if !closeTo(admin, 1.25) {
+		t.Errorf("misc entry admin hours = %v, want the misc rule's 1.25", admin)
+	}
+	_, _, _, admin = rounding.entryHours(Entry{Type: "admin", AdminHours: &hours})
+	if !closeTo(admin, 1.3) {
+		t.Errorf("admin entry admin hours = %v, want the admin rule's 1.3", admin)
+	}
+}
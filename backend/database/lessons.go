@@ -0,0 +1,393 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+var lessonTypes = map[string]bool{"flight": true, "ground": true, "sim": true}
+
+// lessonStatuses can be set directly; "completed" is only reached through
+// CompleteLesson so every completed lesson has an entry.
+var lessonStatuses = map[string]bool{"scheduled": true, "cancelled": true, "no_show": true}
+
+// lessonSelect joins each lesson to its entry so completed lessons carry the
+// hours actually logged.
+const lessonSelect = `
+	SELECT l.id, l.date, COALESCE(l.time, ''), l.type, l.planned_hours, l.customer, l.customer_id,
+		l.status, l.entry_id, l.notes, COALESCE(l.created_at, ''),
+		COALESCE(e.flight_hours, 0) + COALESCE(e.ground_hours, 0) + COALESCE(e.sim_hours, 0)
+	FROM scheduled_lessons l
+	LEFT JOIN pay_entries e ON e.id = l.entry_id
+`
+
+func scanLesson(row rowScanner) (ScheduledLesson, error) {
+	var lesson ScheduledLesson
+	err := row.Scan(&lesson.ID, &lesson.Date, &lesson.Time, &lesson.Type, &lesson.PlannedHours,
+		&lesson.Customer, &lesson.CustomerID, &lesson.Status, &lesson.EntryID, &lesson.Notes,
+		&lesson.CreatedAt, &lesson.ActualHours)
+	lesson.Date = strings.Split(lesson.Date, "T")[0]
+	return lesson, err
+}
+
+func validateLesson(lesson ScheduledLesson) string {
+	if _, err := time.Parse("2006-01-02", lesson.Date); err != nil {
+		return fmt.Sprintf("Invalid lesson date '%s', expected YYYY-MM-DD", lesson.Date)
+	}
+	if !lessonTypes[lesson.Type] {
+		return "Invalid lesson type. Use: flight, ground, or sim"
+	}
+	if lesson.PlannedHours <= 0 {
+		return "Planned hours must be greater than zero"
+	}
+	return ""
+}
+
+func (database *Database) CreateLesson(lesson ScheduledLesson) Response {
+	if msg := validateLesson(lesson); msg != "" {
+		return Response{
+			Status:  "ERROR",
+			Message: msg,
+		}
+	}
+
+	var err error
+	lesson.CustomerID, lesson.Customer, err = database.ResolveCustomer(lesson.Customer)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("error resolving customer: %v", err),
+		}
+	}
+
+	result, err := database.Exec(`
+		INSERT INTO scheduled_lessons (date, time, type, planned_hours, customer, customer_id, notes)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, lesson.Date, nullIfEmpty(lesson.Time), lesson.Type, lesson.PlannedHours,
+		nilCheck(lesson.Customer), nilCheck(lesson.CustomerID), nilCheck(lesson.Notes))
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("error creating lesson: %v", err),
+		}
+	}
+	newID, err := result.LastInsertId()
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("created lesson, ID error: %v", err),
+		}
+	}
+
+	log.Printf("Created lesson ID: %d\n", newID)
+	return Response{
+		Status:  "OK",
+		Message: "New lesson created:",
+		Data:    json.RawMessage(fmt.Sprintf(`{"lesson_id": %d}`, newID)),
+	}
+}
+
+// UpdateLesson reschedules or edits a lesson that has not been completed.
+func (database *Database) UpdateLesson(lesson ScheduledLesson) Response {
+	if msg := validateLesson(lesson); msg != "" {
+		return Response{
+			Status:  "ERROR",
+			Message: msg,
+		}
+	}
+
+	var err error
+	lesson.CustomerID, lesson.Customer, err = database.ResolveCustomer(lesson.Customer)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("error resolving customer: %v", err),
+		}
+	}
+
+	result, err := database.Exec(`
+		UPDATE scheduled_lessons
+		SET date = ?, time = ?, type = ?, planned_hours = ?, customer = ?, customer_id = ?, notes = ?
+		WHERE id = ? AND status != 'completed'
+	`, lesson.Date, nullIfEmpty(lesson.Time), lesson.Type, lesson.PlannedHours,
+		nilCheck(lesson.Customer), nilCheck(lesson.CustomerID), nilCheck(lesson.Notes), lesson.ID)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to update lesson ID=%d: %s", lesson.ID, err),
+		}
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to find open lesson ID=%d", lesson.ID),
+		}
+	}
+
+	log.Printf("Updated lesson ID: %d\n", lesson.ID)
+	return Response{
+		Status:  "OK",
+		Message: "Updated lesson:",
+		Data:    json.RawMessage(fmt.Sprintf(`{"lesson_id": %d}`, lesson.ID)),
+	}
+}
+
+// SetLessonStatus marks an open lesson cancelled or a no-show, or reopens it.
+func (database *Database) SetLessonStatus(id int, status string) Response {
+	if !lessonStatuses[status] {
+		return Response{
+			Status:  "ERROR",
+			Message: "Invalid lesson status. Use: scheduled, cancelled, or no_show",
+		}
+	}
+
+	result, err := database.Exec(
+		"UPDATE scheduled_lessons SET status = ? WHERE id = ? AND status != 'completed'", status, id)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to update lesson ID=%d: %s", id, err),
+		}
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to find open lesson ID=%d", id),
+		}
+	}
+
+	log.Printf("Lesson ID %d marked %s\n", id, status)
+	return Response{
+		Status:  "OK",
+		Message: fmt.Sprintf("Lesson marked %s:", status),
+		Data:    json.RawMessage(fmt.Sprintf(`{"lesson_id": %d}`, id)),
+	}
+}
+
+func (database *Database) GetLesson(id int) (ScheduledLesson, error) {
+	lesson, err := scanLesson(database.QueryRow(lessonSelect+" WHERE l.id = ?", id))
+	if err != nil {
+		return lesson, fmt.Errorf("failed to get lesson: %w", err)
+	}
+	return lesson, nil
+}
+
+// GetLessons lists lessons in an inclusive date range, soonest first,
+// optionally only those with the given status.
+func (database *Database) GetLessons(startDate, endDate, status string) ([]ScheduledLesson, error) {
+	query := lessonSelect + " WHERE l.date BETWEEN ? AND ?"
+	args := []interface{}{startDate, endDate}
+	if status != "" {
+		query += " AND l.status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY l.date, l.time"
+
+	rows, err := database.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lessons: %w", err)
+	}
+	defer rows.Close()
+
+	lessons := []ScheduledLesson{}
+	for rows.Next() {
+		lesson, err := scanLesson(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan lesson: %w", err)
+		}
+		lessons = append(lessons, lesson)
+	}
+	return lessons, rows.Err()
+}
+
+// LessonDateRange - the first and last scheduled lesson dates, today when
+// none are scheduled; resolves the "all" view for lessons
+func (database *Database) LessonDateRange() (string, string, error) {
+	var minDate, maxDate sql.NullString
+	err := database.QueryRow("SELECT MIN(date), MAX(date) FROM scheduled_lessons").Scan(&minDate, &maxDate)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get lesson date range: %w", err)
+	}
+	if !minDate.Valid || !maxDate.Valid {
+		today := time.Now().Format("2006-01-02")
+		return today, today, nil
+	}
+	return strings.Split(minDate.String, "T")[0], strings.Split(maxDate.String, "T")[0], nil
+}
+
+// CompleteLesson logs a pay entry for an open lesson and links the two.
+// Fields left empty on entry are filled from the lesson; when no hours are
+// given the planned hours are logged under the lesson's type.
+func (database *Database) CompleteLesson(id int, entry Entry) Response {
+	lesson, err := database.GetLesson(id)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to find lesson ID=%d", id),
+		}
+	}
+	if lesson.Status == "completed" {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Lesson ID=%d is already completed", id),
+		}
+	}
+
+	if entry.Date == "" {
+		entry.Date = lesson.Date
+	}
+	if entry.Time == "" {
+		entry.Time = lesson.Time
+	}
+	if entry.Type == "" {
+		entry.Type = lesson.Type
+	}
+	if entry.Customer == nil {
+		entry.Customer = lesson.Customer
+	}
+	if entry.Notes == nil {
+		entry.Notes = lesson.Notes
+	}
+	if entry.FlightHours == nil && entry.GroundHours == nil && entry.SimHours == nil {
+		hours := lesson.PlannedHours
+		switch lesson.Type {
+		case "flight":
+			entry.FlightHours = &hours
+		case "ground":
+			entry.GroundHours = &hours
+		case "sim":
+			entry.SimHours = &hours
+		}
+	}
+
+	periodID, msg := database.prepareNewEntry(&entry)
+	if msg != "" {
+		return Response{
+			Status:  "ERROR",
+			Message: msg,
+		}
+	}
+
+	tx, err := database.Begin()
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to complete lesson ID=%d: %s", id, err),
+		}
+	}
+	defer tx.Rollback()
+
+	entryID, err := insertEntry(tx, periodID, entry)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: err.Error(),
+		}
+	}
+	result, err := tx.Exec(`UPDATE scheduled_lessons SET status = 'completed', entry_id = ?
+		WHERE id = ? AND status != 'completed'`, entryID, id)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to complete lesson ID=%d: %s", id, err),
+		}
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Lesson ID=%d is already completed", id),
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to complete lesson ID=%d: %s", id, err),
+		}
+	}
+	database.entryAdded(periodID, entry.Date)
+
+	log.Printf("Completed lesson ID: %d as entry ID: %d\n", id, entryID)
+	return Response{
+		Status:  "OK",
+		Message: "Lesson completed:",
+		Data:    json.RawMessage(fmt.Sprintf(`{"lesson_id": %d, "entry_id": %d}`, id, entryID)),
+	}
+}
+
+// GetLessonSummaries - lesson outcomes for each pay period overlapping the
+// range, clipped to it. Lost income uses the rate in effect on each
+// cancelled or no-show lesson's date.
+func (database *Database) GetLessonSummaries(startDate, endDate string) ([]LessonSummary, error) {
+	begin, err := time.Parse("2006-01-02", startDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start date: %w", err)
+	}
+	end, err := time.Parse("2006-01-02", endDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end date: %w", err)
+	}
+
+	summaries := []LessonSummary{}
+	byStart := map[string]*LessonSummary{}
+	for day := begin; !day.After(end); {
+		periodStart, periodEnd := PeriodBounds(day)
+		if periodStart.Before(begin) {
+			periodStart = begin
+		}
+		if periodEnd.After(end) {
+			periodEnd = end
+		}
+		summaries = append(summaries, LessonSummary{
+			BeginDate: periodStart.Format("2006-01-02"),
+			EndDate:   periodEnd.Format("2006-01-02"),
+		})
+		day = periodEnd.AddDate(0, 0, 1)
+	}
+	for i := range summaries {
+		byStart[summaries[i].BeginDate] = &summaries[i]
+	}
+
+	lessons, err := database.GetLessons(startDate, endDate, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, lesson := range lessons {
+		date, _ := time.Parse("2006-01-02", lesson.Date)
+		periodStart, _ := PeriodBounds(date)
+		key := periodStart.Format("2006-01-02")
+		if key < startDate {
+			key = startDate
+		}
+		summary := byStart[key]
+		if summary == nil {
+			continue
+		}
+
+		summary.PlannedHours += lesson.PlannedHours
+		switch lesson.Status {
+		case "scheduled":
+			summary.Scheduled++
+		case "completed":
+			summary.Completed++
+			summary.CompletedHours += lesson.PlannedHours
+			summary.ActualHours += lesson.ActualHours
+		case "cancelled", "no_show":
+			if lesson.Status == "cancelled" {
+				summary.Cancelled++
+			} else {
+				summary.NoShows++
+			}
+			rate, err := database.GetCurrentRates(lesson.Date)
+			if err != nil {
+				return nil, err
+			}
+			summary.LostHours += lesson.PlannedHours
+			summary.LostIncome += lesson.PlannedHours * rate.Hourly(lesson.Type)
+		}
+	}
+	return summaries, nil
+}
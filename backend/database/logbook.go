@@ -0,0 +1,137 @@
+package database
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// logbookColumns are the pay_entries columns behind Entry's logbook fields,
+// in the order of logbookValues and logbookTargets.
+const logbookColumns = `tail_number, aircraft_type, departure, arrival,
+	day_landings, night_landings, night_hours, ifr_hours, xc_hours,
+	hobbs_start, hobbs_end, tach_start, tach_end`
+
+func (entry *Entry) logbookValues() []interface{} {
+	return []interface{}{
+		nilCheck(entry.TailNumber), nilCheck(entry.AircraftType),
+		nilCheck(entry.Departure), nilCheck(entry.Arrival),
+		nilCheck(entry.DayLandings), nilCheck(entry.NightLandings),
+		nilCheck(entry.NightHours), nilCheck(entry.IFRHours), nilCheck(entry.XCHours),
+		nilCheck(entry.HobbsStart), nilCheck(entry.HobbsEnd),
+		nilCheck(entry.TachStart), nilCheck(entry.TachEnd),
+	}
+}
+
+func (entry *Entry) logbookTargets() []interface{} {
+	return []interface{}{
+		&entry.TailNumber, &entry.AircraftType, &entry.Departure, &entry.Arrival,
+		&entry.DayLandings, &entry.NightLandings,
+		&entry.NightHours, &entry.IFRHours, &entry.XCHours,
+		&entry.HobbsStart, &entry.HobbsEnd, &entry.TachStart, &entry.TachEnd,
+	}
+}
+
+func (entry *Entry) hasLogbook() bool {
+	for _, value := range entry.logbookValues() {
+		if value != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// keepLogbook fills the logbook fields an update leaves out from the entry
+// as stored, so a form that doesn't show them can't wipe them. Send a field
+// as null (or a text field as an empty string) to clear it.
+func (entry *Entry) keepLogbook(stored Entry) {
+	keepUnlessCleared(entry, "tail_number", &entry.TailNumber, stored.TailNumber)
+	keepUnlessCleared(entry, "aircraft_type", &entry.AircraftType, stored.AircraftType)
+	keepUnlessCleared(entry, "departure", &entry.Departure, stored.Departure)
+	keepUnlessCleared(entry, "arrival", &entry.Arrival, stored.Arrival)
+	keepUnlessCleared(entry, "day_landings", &entry.DayLandings, stored.DayLandings)
+	keepUnlessCleared(entry, "night_landings", &entry.NightLandings, stored.NightLandings)
+	keepUnlessCleared(entry, "night_hours", &entry.NightHours, stored.NightHours)
+	keepUnlessCleared(entry, "ifr_hours", &entry.IFRHours, stored.IFRHours)
+	keepUnlessCleared(entry, "xc_hours", &entry.XCHours, stored.XCHours)
+	keepUnlessCleared(entry, "hobbs_start", &entry.HobbsStart, stored.HobbsStart)
+	keepUnlessCleared(entry, "hobbs_end", &entry.HobbsEnd, stored.HobbsEnd)
+	keepUnlessCleared(entry, "tach_start", &entry.TachStart, stored.TachStart)
+	keepUnlessCleared(entry, "tach_end", &entry.TachEnd, stored.TachEnd)
+}
+
+func keepUnlessCleared[T any](entry *Entry, name string, field **T, stored *T) {
+	if !entry.cleared[name] {
+		keepIfNil(field, stored)
+	}
+}
+
+// hobbsTolerance is how far flight hours may be from the Hobbs difference;
+// the meter reads in tenths, so 1:20 logged against 1.3 on the Hobbs agrees.
+const hobbsTolerance = 0.05
+
+// prepareLogbook normalizes and checks the logbook fields before an entry is
+// saved. Identifiers are upper-cased. With both Hobbs readings, flight hours
+// left out are taken from them, and flight hours given must agree with them.
+func (entry *Entry) prepareLogbook() error {
+	for _, field := range []**string{&entry.TailNumber, &entry.AircraftType, &entry.Departure, &entry.Arrival} {
+		if *field == nil {
+			continue
+		}
+		value := strings.TrimSpace(**field)
+		if value == "" {
+			*field = nil
+			continue
+		}
+		if field != &entry.AircraftType {
+			value = strings.ToUpper(value)
+		}
+		*field = &value
+	}
+
+	if !entry.hasLogbook() {
+		return nil
+	}
+	if entry.Type != "flight" {
+		return fmt.Errorf("logbook fields are only recorded on flight entries")
+	}
+
+	for _, landings := range []*int{entry.DayLandings, entry.NightLandings} {
+		if landings != nil && *landings < 0 {
+			return fmt.Errorf("landings cannot be negative")
+		}
+	}
+	for _, hours := range []*float64{entry.NightHours, entry.IFRHours, entry.XCHours} {
+		if hours != nil && *hours < 0 {
+			return fmt.Errorf("night, IFR and cross-country time cannot be negative")
+		}
+	}
+	if entry.TachStart != nil && entry.TachEnd != nil && *entry.TachEnd < *entry.TachStart {
+		return fmt.Errorf("tach end %.1f is before tach start %.1f", *entry.TachEnd, *entry.TachStart)
+	}
+
+	if entry.HobbsStart != nil && entry.HobbsEnd != nil {
+		if *entry.HobbsEnd < *entry.HobbsStart {
+			return fmt.Errorf("hobbs end %.1f is before hobbs start %.1f", *entry.HobbsEnd, *entry.HobbsStart)
+		}
+		hobbs := math.Round((*entry.HobbsEnd-*entry.HobbsStart)*100) / 100
+		if entry.FlightHours == nil {
+			entry.FlightHours = &hobbs
+		} else if math.Abs(*entry.FlightHours-hobbs) >= hobbsTolerance {
+			return fmt.Errorf("flight hours %.2f don't match the %.1f on the hobbs readings; send matching readings, or null to clear them",
+				*entry.FlightHours, hobbs)
+		}
+	}
+
+	flightHours := nilFloat(entry.FlightHours)
+	conditions := []struct {
+		name  string
+		hours *float64
+	}{{"night", entry.NightHours}, {"IFR", entry.IFRHours}, {"cross-country", entry.XCHours}}
+	for _, condition := range conditions {
+		if condition.hours != nil && *condition.hours > flightHours+1e-9 {
+			return fmt.Errorf("%s time %.2f is more than the %.2f flight hours", condition.name, *condition.hours, flightHours)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package database
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func countOf(value int) *int {
+	return &value
+}
+
+func TestPrepareLogbook(t *testing.T) {
+	tests := []struct {
+		name        string
+		entry       Entry
+		wantHours   *float64
+		wantErr     string
+		wantTail    string
+		wantAirType string
+	}{
+		{
+			name:      "hours from hobbs when none given",
+			entry:     Entry{Type: "flight", HobbsStart: hoursOf(1200.3), HobbsEnd: hoursOf(1201.8)},
+			wantHours: hoursOf(1.5),
+		},
+		{
+			name:      "hours given that agree with hobbs are kept",
+			entry:     Entry{Type: "flight", FlightHours: hoursOf(1 + 20.0/60), HobbsStart: hoursOf(100), HobbsEnd: hoursOf(101.3)},
+			wantHours: hoursOf(1 + 20.0/60),
+		},
+		{
+			name:    "hours that disagree with hobbs",
+			entry:   Entry{Type: "flight", FlightHours: hoursOf(2), HobbsStart: hoursOf(100), HobbsEnd: hoursOf(101.5)},
+			wantErr: "don't match",
+		},
+		{
+			name:    "hobbs running backwards",
+			entry:   Entry{Type: "flight", HobbsStart: hoursOf(101.5), HobbsEnd: hoursOf(100)},
+			wantErr: "hobbs end",
+		},
+		{
+			name:    "tach running backwards",
+			entry:   Entry{Type: "flight", FlightHours: hoursOf(1), TachStart: hoursOf(50), TachEnd: hoursOf(49)},
+			wantErr: "tach end",
+		},
+		{
+			name:    "negative landings",
+			entry:   Entry{Type: "flight", FlightHours: hoursOf(1), DayLandings: countOf(-1)},
+			wantErr: "landings",
+		},
+		{
+			name:    "night time past flight time",
+			entry:   Entry{Type: "flight", FlightHours: hoursOf(1), NightHours: hoursOf(1.2)},
+			wantErr: "night time",
+		},
+		{
+			name:    "logbook fields on a ground entry",
+			entry:   Entry{Type: "ground", GroundHours: hoursOf(1), DayLandings: countOf(1)},
+			wantErr: "only recorded on flight entries",
+		},
+		{
+			name:        "identifiers normalized",
+			entry:       Entry{Type: "flight", FlightHours: hoursOf(1), TailNumber: textOf(" n12345 "), AircraftType: textOf("C172")},
+			wantHours:   hoursOf(1),
+			wantTail:    "N12345",
+			wantAirType: "C172",
+		},
+		{
+			name:      "blank identifiers on a ground entry are dropped",
+			entry:     Entry{Type: "ground", GroundHours: hoursOf(1), TailNumber: textOf("  ")},
+			wantHours: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := tt.entry
+			err := entry.prepareLogbook()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("prepareLogbook() = %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("prepareLogbook(): %v", err)
+			}
+			if (entry.FlightHours == nil) != (tt.wantHours == nil) ||
+				entry.FlightHours != nil && !closeTo(*entry.FlightHours, *tt.wantHours) {
+				t.Errorf("flight hours = %v, want %v", entry.FlightHours, tt.wantHours)
+			}
+			if tt.wantTail != "" && deref(entry.TailNumber) != tt.wantTail {
+				t.Errorf("tail = %q, want %q", deref(entry.TailNumber), tt.wantTail)
+			}
+			if tt.wantAirType != "" && deref(entry.AircraftType) != tt.wantAirType {
+				t.Errorf("aircraft type = %q, want %q", deref(entry.AircraftType), tt.wantAirType)
+			}
+			if tt.entry.Type == "ground" && entry.TailNumber != nil {
+				t.Errorf("blank tail kept as %q", *entry.TailNumber)
+			}
+		})
+	}
+}
+
+func deref(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func TestKeepLogbook(t *testing.T) {
+	stored := Entry{
+		TailNumber: textOf("N12345"), DayLandings: countOf(3), NightHours: hoursOf(0.5),
+		HobbsStart: hoursOf(100), HobbsEnd: hoursOf(101.5),
+	}
+
+	var edit Entry
+	body := `{"type":"flight","flight_hours":1.5,"tail_number":"N54321","day_landings":null,"night_hours":0.2}`
+	if err := json.Unmarshal([]byte(body), &edit); err != nil {
+		t.Fatal(err)
+	}
+	edit.keepLogbook(stored)
+
+	if deref(edit.TailNumber) != "N54321" {
+		t.Errorf("tail = %q, want the sent N54321", deref(edit.TailNumber))
+	}
+	if edit.DayLandings != nil {
+		t.Errorf("day landings = %d, want cleared by null", *edit.DayLandings)
+	}
+	if edit.NightHours == nil || *edit.NightHours != 0.2 {
+		t.Errorf("night hours = %v, want the sent 0.2", edit.NightHours)
+	}
+	if edit.HobbsStart == nil || *edit.HobbsStart != 100 || edit.HobbsEnd == nil || *edit.HobbsEnd != 101.5 {
+		t.Errorf("hobbs = %v..%v, want the stored readings kept", edit.HobbsStart, edit.HobbsEnd)
+	}
+}
+
+func TestUpdateEntryLogbook(t *testing.T) {
+	database := openTestDB(t)
+
+	response := database.NewEntry(Entry{Type: "flight", Date: "2025-03-03", Time: "08:00",
+		TailNumber: textOf("N12345"), DayLandings: countOf(2), HobbsStart: hoursOf(100), HobbsEnd: hoursOf(101.5)})
+	if response.Status != "OK" {
+		t.Fatal(response.Message)
+	}
+	var created struct {
+		EntryID int `json:"entry_id"`
+	}
+	json.Unmarshal(response.Data, &created)
+
+	update := func(body string) Response {
+		t.Helper()
+		var entry Entry
+		if err := json.Unmarshal([]byte(body), &entry); err != nil {
+			t.Fatal(err)
+		}
+		entry.ID = created.EntryID
+		return database.UpdateEntry(entry)
+	}
+	stored := func() Entry {
+		t.Helper()
+		entries, _, err := database.FetchFilteredEntries(EntryFilter{})
+		if err != nil || len(entries) != 1 {
+			t.Fatalf("entries = %v, %v", entries, err)
+		}
+		return entries[0]
+	}
+
+	// the edit form sends no hobbs; changing flight hours must not be
+	// silently put back from the stored readings
+	response = update(`{"type":"flight","date":"2025-03-03","time":"08:00","flight_hours":2}`)
+	if response.Status != "ERROR" || !strings.Contains(response.Message, "don't match") {
+		t.Errorf("edit against stored hobbs = %+v, want a mismatch error", response)
+	}
+	if entry := stored(); *entry.FlightHours != 1.5 {
+		t.Errorf("flight hours = %v after a refused edit, want 1.5", *entry.FlightHours)
+	}
+
+	// clearing the readings lets the new hours stand; other fields are kept
+	response = update(`{"type":"flight","date":"2025-03-03","time":"08:00","flight_hours":2,"hobbs_start":null,"hobbs_end":null}`)
+	if response.Status != "OK" {
+		t.Fatal(response.Message)
+	}
+	entry := stored()
+	if *entry.FlightHours != 2 || entry.HobbsStart != nil || entry.HobbsEnd != nil {
+		t.Errorf("after clearing hobbs: %v hours, hobbs %v..%v", *entry.FlightHours, entry.HobbsStart, entry.HobbsEnd)
+	}
+	if deref(entry.TailNumber) != "N12345" || entry.DayLandings == nil || *entry.DayLandings != 2 {
+		t.Errorf("omitted fields not kept: tail %q, landings %v", deref(entry.TailNumber), entry.DayLandings)
+	}
+
+	// new readings sent without hours set them
+	response = update(`{"type":"flight","date":"2025-03-03","time":"08:00","hobbs_start":200,"hobbs_end":201.2,"day_landings":null}`)
+	if response.Status != "OK" {
+		t.Fatal(response.Message)
+	}
+	entry = stored()
+	if !closeTo(*entry.FlightHours, 1.2) || entry.DayLandings != nil {
+		t.Errorf("after new hobbs: %v hours, landings %v; want 1.2 and cleared", *entry.FlightHours, entry.DayLandings)
+	}
+}
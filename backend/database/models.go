@@ -12,9 +12,29 @@ type Entry struct {
 	SimHours    *float64 `json:"sim_hours,omitempty"`
 	AdminHours  *float64 `json:"admin_hours,omitempty"`
 	Customer    *string  `json:"customer,omitempty"`
+	CustomerID  *int     `json:"customer_id,omitempty"`
 	Notes       *string  `json:"notes,omitempty"`
 	RideCount   *int     `json:"ride_count,omitempty"`
 	Meeting     bool     `json:"meeting"`
+
+	// logbook fields for flight entries
+	TailNumber    *string  `json:"tail_number,omitempty"`
+	AircraftType  *string  `json:"aircraft_type,omitempty"`
+	Departure     *string  `json:"departure,omitempty"`
+	Arrival       *string  `json:"arrival,omitempty"`
+	DayLandings   *int     `json:"day_landings,omitempty"`
+	NightLandings *int     `json:"night_landings,omitempty"`
+	NightHours    *float64 `json:"night_hours,omitempty"`
+	IFRHours      *float64 `json:"ifr_hours,omitempty"`
+	XCHours       *float64 `json:"xc_hours,omitempty"`
+	HobbsStart    *float64 `json:"hobbs_start,omitempty"`
+	HobbsEnd      *float64 `json:"hobbs_end,omitempty"`
+	TachStart     *float64 `json:"tach_start,omitempty"`
+	TachEnd       *float64 `json:"tach_end,omitempty"`
+
+	// cleared names the fields a request sent as null, which an update
+	// empties instead of keeping the stored value
+	cleared map[string]bool
 }
 
 type Paycheck struct {
@@ -25,20 +45,359 @@ type Paycheck struct {
 	GrossEarned *float64 `json:"gross_earnings,omitempty"`
 	GrossActual *float64 `json:"gross_actual,omitempty"`
 	NetActual   *float64 `json:"net_actual,omitempty"`
+	NetEstimate *float64 `json:"net_estimate,omitempty"`
 	LastUpdated string   `json:"last_updated"`
 	TotalHours  *float64 `json:"total_hours,omitempty"`
 	Status      string   `json:"status"`
 }
 
+type Customer struct {
+	ID         int      `json:"id"`
+	Name       string   `json:"name"`
+	Aliases    []string `json:"aliases"`
+	Active     bool     `json:"active"`
+	EntryCount int      `json:"entry_count"`
+	CreatedAt  string   `json:"created_at,omitempty"`
+}
+
+type CustomerProgress struct {
+	CustomerID  int             `json:"customer_id"`
+	Customer    string          `json:"customer"`
+	FlightHours float64         `json:"flight_hours"`
+	GroundHours float64         `json:"ground_hours"`
+	SimHours    float64         `json:"sim_hours"`
+	TotalHours  float64         `json:"total_hours"`
+	Lessons     int             `json:"lessons"`
+	FirstLesson string          `json:"first_lesson"`
+	LastLesson  string          `json:"last_lesson"`
+	Timeline    []ProgressPoint `json:"timeline"`
+}
+
+// ProgressPoint is one lesson in a customer's timeline, with running totals
+// including that lesson.
+type ProgressPoint struct {
+	EntryID          int     `json:"entry_id"`
+	Date             string  `json:"date"`
+	Type             string  `json:"type"`
+	FlightHours      float64 `json:"flight_hours"`
+	GroundHours      float64 `json:"ground_hours"`
+	SimHours         float64 `json:"sim_hours"`
+	Notes            string  `json:"notes,omitempty"`
+	CumulativeFlight float64 `json:"cumulative_flight"`
+	CumulativeGround float64 `json:"cumulative_ground"`
+	CumulativeSim    float64 `json:"cumulative_sim"`
+}
+
 type PayRate struct {
+	ID                 int      `json:"id"`
+	EffectiveDate      string   `json:"effective_date"`
+	CFIRate            float64  `json:"cfi_rate"`
+	AdminRate          float64  `json:"admin_rate"`
+	FlightRate         *float64 `json:"flight_rate,omitempty"`
+	GroundRate         *float64 `json:"ground_rate,omitempty"`
+	SimRate            *float64 `json:"sim_rate,omitempty"`
+	MiscRate           *float64 `json:"misc_rate,omitempty"`
+	MeetingAmount      *float64 `json:"meeting_amount,omitempty"`
+	MeetingHours       *float64 `json:"meeting_hours,omitempty"`
+	RideCreditHours    *float64 `json:"ride_credit_hours"`
+	RideCreditAmount   *float64 `json:"ride_credit_amount,omitempty"`
+	OvertimeThreshold  *float64 `json:"overtime_threshold,omitempty"`
+	OvertimeMultiplier float64  `json:"overtime_multiplier"`
+	LastUpdated        string   `json:"last_updated"`
+}
+
+// Premium is extra pay for matching entries, e.g. checkrides or night
+// flights, in effect from EffectiveDate until EndDate (open-ended if empty).
+type Premium struct {
+	ID            int     `json:"id"`
+	EffectiveDate string  `json:"effective_date"`
+	EndDate       string  `json:"end_date,omitempty"`
+	Name          string  `json:"name"`
+	EntryType     string  `json:"entry_type,omitempty"`
+	Keyword       string  `json:"keyword,omitempty"`
+	FlatAmount    float64 `json:"flat_amount"`
+	HourlyAmount  float64 `json:"hourly_amount"`
+}
+
+type EntryFilter struct {
+	BeginDate  string
+	EndDate    string
+	Types      []string
+	Customer   string
+	CustomerID int
+	Tail       string
+	Meeting    *bool
+	Search     string
+	SortBy     string
+	SortDesc   bool
+	Limit      int
+	Offset     int
+}
+
+type Pagination struct {
+	Total  int `json:"total"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// CategoryPay is one line of a totals breakdown by pay category.
+type CategoryPay struct {
+	Hours float64 `json:"hours"`
+	Count int     `json:"count"`
+	Pay   float64 `json:"pay"`
+}
+
+// EntryPayLine is the base pay one entry earned in one category, as the
+// totals engine priced it.
+type EntryPayLine struct {
+	Entry    Entry
+	Category string
+	CategoryPay
+}
+
+type PremiumLine struct {
+	Name    string  `json:"name"`
+	Entries int     `json:"entries"`
+	Amount  float64 `json:"amount"`
+}
+
+type MonthlyStats struct {
+	Year        int     `json:"year"`
+	Month       int     `json:"month"`
+	FlightHours float64 `json:"flight_hours"`
+	GroundHours float64 `json:"ground_hours"`
+	SimHours    float64 `json:"sim_hours"`
+	AdminHours  float64 `json:"admin_hours"`
+	GrossPay    float64 `json:"gross_pay"`
+	LastUpdated string  `json:"last_updated"`
+}
+
+type PeriodSummary struct {
+	ID            int      `json:"id"`
+	BeginDate     string   `json:"begin_date"`
+	EndDate       string   `json:"end_date"`
+	PayDate       string   `json:"pay_date"`
+	TotalHours    float64  `json:"total_hours"`
+	ExpectedGross float64  `json:"expected_gross"`
+	ActualGross   *float64 `json:"actual_gross,omitempty"`
+	ActualNet     *float64 `json:"actual_net,omitempty"`
+	Open          bool     `json:"open"` // still running on the summary's as-of date
+}
+
+type YearSummary struct {
+	Year               int             `json:"year"`
+	AsOf               string          `json:"as_of"`
+	Periods            int             `json:"periods"`
+	ExpectedGross      float64         `json:"expected_gross"`
+	ActualGross        float64         `json:"actual_gross"`
+	ActualNet          float64         `json:"actual_net"`
+	PeriodsWithActuals int             `json:"periods_with_actuals"`
+	FlightHours        float64         `json:"flight_hours"`
+	GroundHours        float64         `json:"ground_hours"`
+	SimHours           float64         `json:"sim_hours"`
+	AdminHours         float64         `json:"admin_hours"`
+	TotalHours         float64         `json:"total_hours"`
+	AvgHoursPerPeriod  float64         `json:"avg_hours_per_period"`
+	BestPeriod         *PeriodSummary  `json:"best_period,omitempty"`
+	WorstPeriod        *PeriodSummary  `json:"worst_period,omitempty"`
+	PeriodDetail       []PeriodSummary `json:"period_detail"`
+}
+
+// Projection estimates gross and hours at the end of a period or year. The
+// inputs it was built from are returned alongside the result.
+type Projection struct {
+	Scope           string   `json:"scope"`
+	BeginDate       string   `json:"begin_date"`
+	EndDate         string   `json:"end_date"`
+	AsOf            string   `json:"as_of"`
+	EarnedGross     float64  `json:"earned_gross"`
+	EarnedHours     float64  `json:"earned_hours"`
+	ScheduledGross  float64  `json:"scheduled_gross"`
+	ScheduledHours  float64  `json:"scheduled_hours"`
+	LessonGross     float64  `json:"lesson_gross"`
+	LessonHours     float64  `json:"lesson_hours"`
+	Lessons         int      `json:"scheduled_lessons"`
+	ScheduledDays   int      `json:"scheduled_days"`
+	RemainingDays   int      `json:"remaining_days"`
+	PaceFrom        string   `json:"pace_from"`
+	PaceDays        int      `json:"pace_days"`
+	PaceGrossPerDay float64  `json:"pace_gross_per_day"`
+	PaceHoursPerDay float64  `json:"pace_hours_per_day"`
+	ProjectedGross  float64  `json:"projected_gross"`
+	ProjectedHours  float64  `json:"projected_hours"`
+	TargetGross     *float64 `json:"target_gross,omitempty"`
+	TargetGap       *float64 `json:"target_gap,omitempty"`
+}
+
+// Goal is a gross or hours target for each period, month or year. A goal
+// applies from its effective date until a newer goal for the same scope and
+// metric replaces it; older rows are kept as history.
+type Goal struct {
+	ID            int     `json:"id"`
+	Scope         string  `json:"scope"`
+	Metric        string  `json:"metric"`
+	Target        float64 `json:"target"`
 	EffectiveDate string  `json:"effective_date"`
-	CFIRate       float64 `json:"cfi_rate"`
-	AdminRate     float64 `json:"admin_rate"`
-	LastUpdated   string  `json:"last_updated"`
+	CreatedAt     string  `json:"created_at,omitempty"`
+}
+
+type GoalProgress struct {
+	Goal                 Goal    `json:"goal"`
+	BeginDate            string  `json:"begin_date"`
+	EndDate              string  `json:"end_date"`
+	Achieved             float64 `json:"achieved"`
+	Remaining            float64 `json:"remaining"`
+	PercentComplete      float64 `json:"percent_complete"`
+	RemainingHoursNeeded float64 `json:"remaining_hours_needed"`
+	Projected            float64 `json:"projected"`
+	OnPace               bool    `json:"on_pace"`
+}
+
+// ScheduledLesson is a planned flight, ground or sim session. Completing it
+// logs a pay entry; cancelled lessons and no-shows are kept for reporting.
+type ScheduledLesson struct {
+	ID           int     `json:"id"`
+	Date         string  `json:"date"`
+	Time         string  `json:"time"`
+	Type         string  `json:"type"`
+	PlannedHours float64 `json:"planned_hours"`
+	Customer     *string `json:"customer,omitempty"`
+	CustomerID   *int    `json:"customer_id,omitempty"`
+	Status       string  `json:"status"`
+	EntryID      *int    `json:"entry_id,omitempty"`
+	ActualHours  float64 `json:"actual_hours"`
+	Notes        *string `json:"notes,omitempty"`
+	CreatedAt    string  `json:"created_at,omitempty"`
+}
+
+// LessonSummary counts scheduled lessons by outcome for one pay period.
+// Completed hours are as planned and actual hours as logged; lost income is
+// what cancelled and no-show lessons would have paid.
+type LessonSummary struct {
+	BeginDate      string  `json:"begin_date"`
+	EndDate        string  `json:"end_date"`
+	Scheduled      int     `json:"scheduled"`
+	Completed      int     `json:"completed"`
+	Cancelled      int     `json:"cancelled"`
+	NoShows        int     `json:"no_shows"`
+	PlannedHours   float64 `json:"planned_hours"`
+	CompletedHours float64 `json:"completed_hours"`
+	ActualHours    float64 `json:"actual_hours"`
+	LostHours      float64 `json:"lost_hours"`
+	LostIncome     float64 `json:"lost_income"`
+}
+
+// DraftEntry is an entry pre-filled from an imported calendar event. It
+// becomes a real entry only once confirmed.
+type DraftEntry struct {
+	ID        int     `json:"id"`
+	Date      string  `json:"date"`
+	Time      string  `json:"time"`
+	Type      string  `json:"type"`
+	Hours     float64 `json:"hours"`
+	Customer  *string `json:"customer,omitempty"`
+	Notes     *string `json:"notes,omitempty"`
+	Meeting   bool    `json:"meeting"`
+	SourceUID string  `json:"source_uid"`
+	Status    string  `json:"status"`
+	EntryID   *int    `json:"entry_id,omitempty"`
+	CreatedAt string  `json:"created_at,omitempty"`
+}
+
+type Aircraft struct {
+	ID         int      `json:"id"`
+	TailNumber string   `json:"tail_number"`
+	MakeModel  string   `json:"make_model"`
+	Category   string   `json:"category"`
+	HourlyRate *float64 `json:"hourly_rate,omitempty"`
+	Active     bool     `json:"active"`
+	CreatedAt  string   `json:"created_at,omitempty"`
+}
+
+// AircraftHours is the flying logged in one aircraft over a date range.
+// Tails that are not in the registry are reported with Registered false;
+// Cost is hours at the aircraft's hourly rate, when one is set.
+type AircraftHours struct {
+	TailNumber string   `json:"tail_number"`
+	MakeModel  string   `json:"make_model"`
+	Category   string   `json:"category"`
+	Registered bool     `json:"registered"`
+	Flights    int      `json:"flights"`
+	Hours      float64  `json:"hours"`
+	NightHours float64  `json:"night_hours"`
+	IFRHours   float64  `json:"ifr_hours"`
+	XCHours    float64  `json:"xc_hours"`
+	Landings   int      `json:"landings"`
+	LastFlown  string   `json:"last_flown"`
+	Cost       *float64 `json:"cost,omitempty"`
+}
+
+// Credential is a certificate, rating, CFI certificate, flight review or
+// medical the instructor holds.
+type Credential struct {
+	ID           int     `json:"id"`
+	Kind         string  `json:"kind"`
+	Name         string  `json:"name"`
+	Number       *string `json:"number,omitempty"`
+	IssuedDate   *string `json:"issued_date,omitempty"`
+	ExpiresDate  *string `json:"expires_date,omitempty"`
+	MedicalClass *int    `json:"medical_class,omitempty"`
+	Notes        *string `json:"notes,omitempty"`
+	CreatedAt    string  `json:"created_at,omitempty"`
+}
+
+// CurrencyItem is one thing that keeps the instructor legal, with how long
+// it has left as of the report date. Status is current, expiring (within
+// the report window) or expired.
+type CurrencyItem struct {
+	Kind          string `json:"kind"`
+	Name          string `json:"name"`
+	CredentialID  *int   `json:"credential_id,omitempty"`
+	ExpiresDate   string `json:"expires_date,omitempty"`
+	DaysRemaining *int   `json:"days_remaining,omitempty"`
+	Status        string `json:"status"`
+	Detail        string `json:"detail,omitempty"`
 }
 
 type Response struct {
-	Status  string          `json:"status"`
-	Message string          `json:"message"`
-	Data    json.RawMessage `json:"data,omitempty"`
+	Status     string          `json:"status"`
+	Message    string          `json:"message"`
+	Data       json.RawMessage `json:"data,omitempty"`
+	Pagination *Pagination     `json:"pagination,omitempty"`
 }
+
+// PayStub is a pay stub uploaded for a pay period, with the figures its
+// configured patterns found.
+type PayStub struct {
+	ID          int              `json:"id"`
+	PayPeriodID *int             `json:"pay_period_id,omitempty"`
+	PayDate     *string          `json:"pay_date,omitempty"`
+	PeriodStart *string          `json:"period_start,omitempty"`
+	PeriodEnd   *string          `json:"period_end,omitempty"`
+	Gross       *float64         `json:"gross,omitempty"`
+	Net         *float64         `json:"net,omitempty"`
+	TotalHours  float64          `json:"total_hours"`
+	Filename    *string          `json:"filename,omitempty"`
+	RawText     string           `json:"-"`
+	UploadedAt  string           `json:"uploaded_at,omitempty"`
+	Earnings    []PayStubEarning `json:"earnings"`
+}
+
+type PayStubEarning struct {
+	Code   string   `json:"code"`
+	Hours  *float64 `json:"hours,omitempty"`
+	Rate   *float64 `json:"rate,omitempty"`
+	Amount float64  `json:"amount"`
+}
+
+// RoundingRule rounds logged time to a multiple of Increment minutes (6
+// for tenths of an hour, 15 for quarter hours). Mode is nearest (the
+// default), up or down.
+type RoundingRule struct {
+	Increment int    `yaml:"increment" json:"increment"`
+	Mode      string `yaml:"mode" json:"mode,omitempty"`
+}
+
+// Rounding maps a pay category (flight, ground, sim, admin, misc) to the
+// rule its hours are rounded by before pay is computed.
+type Rounding map[string]RoundingRule
@@ -1,12 +1,287 @@
 package database
 
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const rateColumns = `
+	id, effective_date, cfi_rate, admin_rate,
+	flight_rate, ground_rate, sim_rate, misc_rate, meeting_amount, meeting_hours,
+	COALESCE(ride_credit_hours, 0.2), ride_credit_amount,
+	overtime_threshold, COALESCE(overtime_multiplier, 1.5), COALESCE(last_updated, '')
+`
+
+// defaultRideCreditHours is credited per ride when a rate doesn't say.
+const defaultRideCreditHours = 0.2
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRate(row rowScanner) (PayRate, error) {
+	var rate PayRate
+	err := row.Scan(&rate.ID, &rate.EffectiveDate, &rate.CFIRate, &rate.AdminRate,
+		&rate.FlightRate, &rate.GroundRate, &rate.SimRate, &rate.MiscRate, &rate.MeetingAmount, &rate.MeetingHours,
+		&rate.RideCreditHours, &rate.RideCreditAmount,
+		&rate.OvertimeThreshold, &rate.OvertimeMultiplier, &rate.LastUpdated)
+	rate.EffectiveDate = strings.Split(rate.EffectiveDate, "T")[0]
+	return rate, err
+}
+
 func (database *Database) CreatePayRate(rate PayRate) Response {
+	if _, err := time.Parse("2006-01-02", rate.EffectiveDate); err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Invalid effective date '%s', expected YYYY-MM-DD", rate.EffectiveDate),
+		}
+	}
+	if rate.OvertimeMultiplier == 0 {
+		rate.OvertimeMultiplier = 1.5
+	}
+	// an explicit 0 credits nothing per ride; only an absent value (with no
+	// flat amount either) takes the default
+	if rate.RideCreditHours == nil && rate.RideCreditAmount == nil {
+		credit := defaultRideCreditHours
+		rate.RideCreditHours = &credit
+	}
+
+	result, err := database.Exec(`
+		INSERT INTO pay_rates (
+			effective_date, cfi_rate, admin_rate,
+			flight_rate, ground_rate, sim_rate, misc_rate, meeting_amount, meeting_hours,
+			ride_credit_hours, ride_credit_amount,
+			overtime_threshold, overtime_multiplier
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, rate.EffectiveDate, rate.CFIRate, rate.AdminRate,
+		nilCheck(rate.FlightRate), nilCheck(rate.GroundRate), nilCheck(rate.SimRate),
+		nilCheck(rate.MiscRate), nilCheck(rate.MeetingAmount), nilCheck(rate.MeetingHours),
+		nilCheck(rate.RideCreditHours), nilCheck(rate.RideCreditAmount),
+		nilCheck(rate.OvertimeThreshold), rate.OvertimeMultiplier)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("error creating pay rate: %v", err),
+		}
+	}
+	newID, err := result.LastInsertId()
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("created pay rate, ID error: %v", err),
+		}
+	}
+
+	database.refreshTotalsFrom(rate.EffectiveDate)
+
+	log.Printf("Created pay rate ID: %d\n", newID)
 	return Response{
 		Status:  "OK",
-		Message: "Pay rate created (TODO)",
+		Message: "Pay rate created:",
+		Data:    json.RawMessage(fmt.Sprintf(`{"rate_id": %d}`, newID)),
 	}
 }
 
-func (database *Database) GetRates(checkID int) ([]PayRate, error) {
-	return nil, nil
+// Hourly returns the rate for a pay category. Flight, ground and sim fall
+// back to the CFI rate and misc to the admin rate when not set separately.
+func (rate PayRate) Hourly(category string) float64 {
+	switch category {
+	case "flight":
+		return orDefault(rate.FlightRate, rate.CFIRate)
+	case "ground":
+		return orDefault(rate.GroundRate, rate.CFIRate)
+	case "sim":
+		return orDefault(rate.SimRate, rate.CFIRate)
+	case "misc":
+		return orDefault(rate.MiscRate, rate.AdminRate)
+	}
+	return rate.AdminRate
+}
+
+// RideCredit returns the admin hours credited per ride.
+func (rate PayRate) RideCredit() float64 {
+	return orDefault(rate.RideCreditHours, defaultRideCreditHours)
+}
+
+func orDefault(value *float64, fallback float64) float64 {
+	if value != nil {
+		return *value
+	}
+	return fallback
+}
+
+// GetRates lists every pay rate, newest first.
+func (database *Database) GetRates() ([]PayRate, error) {
+	rows, err := database.Query("SELECT " + rateColumns + " FROM pay_rates ORDER BY effective_date DESC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rates: %w", err)
+	}
+	defer rows.Close()
+
+	rates := []PayRate{}
+	for rows.Next() {
+		rate, err := scanRate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan rate: %w", err)
+		}
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
+
+func (database *Database) CreatePremium(premium Premium) Response {
+	if strings.TrimSpace(premium.Name) == "" {
+		return Response{
+			Status:  "ERROR",
+			Message: "Premium name is required",
+		}
+	}
+	dates := []string{premium.EffectiveDate}
+	if premium.EndDate != "" {
+		dates = append(dates, premium.EndDate)
+	}
+	for _, date := range dates {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Invalid date '%s', expected YYYY-MM-DD", date),
+			}
+		}
+	}
+
+	result, err := database.Exec(`
+		INSERT INTO pay_premiums (effective_date, end_date, name, entry_type, keyword, flat_amount, hourly_amount)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, premium.EffectiveDate, nullIfEmpty(premium.EndDate), strings.TrimSpace(premium.Name),
+		nullIfEmpty(premium.EntryType), nullIfEmpty(premium.Keyword),
+		premium.FlatAmount, premium.HourlyAmount)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("error creating premium: %v", err),
+		}
+	}
+	newID, err := result.LastInsertId()
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("created premium, ID error: %v", err),
+		}
+	}
+
+	database.refreshTotalsFrom(premium.EffectiveDate)
+
+	log.Printf("Created premium ID: %d\n", newID)
+	return Response{
+		Status:  "OK",
+		Message: "Premium created:",
+		Data:    json.RawMessage(fmt.Sprintf(`{"premium_id": %d}`, newID)),
+	}
+}
+
+// GetPremiums lists premiums overlapping [startDate, endDate], or every
+// premium when both are empty.
+func (database *Database) GetPremiums(startDate, endDate string) ([]Premium, error) {
+	query := `
+		SELECT id, effective_date, COALESCE(end_date, ''), name, COALESCE(entry_type, ''),
+		       COALESCE(keyword, ''), COALESCE(flat_amount, 0), COALESCE(hourly_amount, 0)
+		FROM pay_premiums
+	`
+	var args []interface{}
+	if startDate != "" && endDate != "" {
+		query += " WHERE effective_date <= ? AND (end_date IS NULL OR end_date >= ?)"
+		args = append(args, endDate, startDate)
+	}
+	query += " ORDER BY effective_date DESC, id DESC"
+
+	rows, err := database.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get premiums: %w", err)
+	}
+	defer rows.Close()
+
+	premiums := []Premium{}
+	for rows.Next() {
+		var premium Premium
+		err := rows.Scan(&premium.ID, &premium.EffectiveDate, &premium.EndDate, &premium.Name,
+			&premium.EntryType, &premium.Keyword, &premium.FlatAmount, &premium.HourlyAmount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan premium: %w", err)
+		}
+		premium.EffectiveDate = strings.Split(premium.EffectiveDate, "T")[0]
+		premium.EndDate = strings.Split(premium.EndDate, "T")[0]
+		premiums = append(premiums, premium)
+	}
+	return premiums, nil
+}
+
+// applies reports whether premium pays out for entry.
+func (premium Premium) applies(entry Entry) bool {
+	date := strings.Split(entry.Date, "T")[0]
+	if date < premium.EffectiveDate || (premium.EndDate != "" && date > premium.EndDate) {
+		return false
+	}
+	if premium.EntryType != "" && premium.EntryType != entry.Type {
+		return false
+	}
+	if premium.Keyword != "" {
+		if entry.Notes == nil || !strings.Contains(strings.ToLower(*entry.Notes), strings.ToLower(premium.Keyword)) {
+			return false
+		}
+	}
+	return true
+}
+
+// refreshTotalsFrom recalculates stored period and monthly totals on or
+// after date, after a pay rule change that could affect them.
+func (database *Database) refreshTotalsFrom(date string) {
+	rows, err := database.Query("SELECT id FROM pay_periods WHERE end_date >= ?", date)
+	if err != nil {
+		log.Printf("Warning: failed to refresh period totals: %v", err)
+		return
+	}
+	var periodIDs []int
+	for rows.Next() {
+		var id int
+		if rows.Scan(&id) == nil {
+			periodIDs = append(periodIDs, id)
+		}
+	}
+	rows.Close()
+
+	for _, id := range periodIDs {
+		if err := database.UpdatePayPeriodTotals(id); err != nil {
+			log.Printf("Warning: failed to refresh period totals: %v", err)
+		}
+	}
+
+	var months []string
+	rows, err = database.Query(
+		"SELECT DISTINCT strftime('%Y-%m-01', date) FROM pay_entries WHERE date >= ?", date)
+	if err != nil {
+		log.Printf("Warning: failed to refresh monthly stats: %v", err)
+		return
+	}
+	for rows.Next() {
+		var month sql.NullString
+		if rows.Scan(&month) == nil && month.Valid {
+			months = append(months, month.String)
+		}
+	}
+	rows.Close()
+
+	for _, month := range months {
+		database.updateMonthlyStatsFor(month)
+	}
+}
+
+func nullIfEmpty(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
 }
@@ -0,0 +1,198 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// MatchPayPeriod finds the pay period a stub belongs to: the one containing
+// the stub's period start when it has one, otherwise the one paid on its
+// pay date. It only reads; an upload never creates or re-statuses periods.
+func (database *Database) MatchPayPeriod(periodStart, payDate string) (Paycheck, error) {
+	condition, date := "? BETWEEN start_date AND end_date", periodStart
+	notFound := "no pay period contains %s"
+	if periodStart == "" {
+		if payDate == "" {
+			return Paycheck{}, fmt.Errorf("stub has neither a period start nor a pay date")
+		}
+		condition, date = "date(pay_date) = ?", payDate
+		notFound = "no pay period is paid on %s"
+	}
+
+	var period Paycheck
+	err := database.QueryRow(`
+		SELECT id, start_date, end_date, pay_date, expected_pay_gross,
+		       actual_pay_gross, actual_pay_net, COALESCE(last_updated, '')
+		FROM pay_periods
+		WHERE `+condition+`
+		ORDER BY start_date DESC
+		LIMIT 1
+	`, date).Scan(
+		&period.ID, &period.BeginDate, &period.EndDate, &period.PayDate,
+		&period.GrossEarned, &period.GrossActual, &period.NetActual,
+		&period.LastUpdated,
+	)
+	if err == sql.ErrNoRows {
+		return Paycheck{}, fmt.Errorf(notFound, date)
+	}
+	if err != nil {
+		return Paycheck{}, fmt.Errorf("failed to match pay period: %w", err)
+	}
+	return period, nil
+}
+
+// RecordPayStub stores an uploaded stub and its earnings lines and writes
+// check to the stub's pay period, all in one transaction. A stub already
+// recorded for the same period, pay date and gross is refused.
+func (database *Database) RecordPayStub(stub PayStub, check Paycheck) Response {
+	tx, err := database.Begin()
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("error creating pay stub: %v", err),
+		}
+	}
+	defer tx.Rollback()
+
+	var existingID int
+	err = tx.QueryRow(`
+		SELECT id FROM pay_stubs
+		WHERE pay_period_id IS ? AND date(pay_date) IS ? AND gross IS ?
+		LIMIT 1
+	`, nilCheck(stub.PayPeriodID), nilCheck(stub.PayDate), nilCheck(stub.Gross)).Scan(&existingID)
+	if err == nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("This pay stub is already recorded (stub ID=%d)", existingID),
+		}
+	}
+	if err != sql.ErrNoRows {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("error checking for a recorded pay stub: %v", err),
+		}
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO pay_stubs (pay_period_id, pay_date, period_start, period_end, gross, net,
+			total_hours, filename, raw_text)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, nilCheck(stub.PayPeriodID), nilCheck(stub.PayDate), nilCheck(stub.PeriodStart),
+		nilCheck(stub.PeriodEnd), nilCheck(stub.Gross), nilCheck(stub.Net), stub.TotalHours,
+		nilCheck(stub.Filename), stub.RawText)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("error creating pay stub: %v", err),
+		}
+	}
+	newID, err := result.LastInsertId()
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("created pay stub, ID error: %v", err),
+		}
+	}
+
+	for _, earning := range stub.Earnings {
+		_, err = tx.Exec(`
+			INSERT INTO pay_stub_earnings (stub_id, code, hours, rate, amount)
+			VALUES (?, ?, ?, ?, ?)
+		`, newID, earning.Code, nilCheck(earning.Hours), nilCheck(earning.Rate), earning.Amount)
+		if err != nil {
+			return Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("error creating pay stub earnings: %v", err),
+			}
+		}
+	}
+
+	affected, err := writePaycheck(tx, check)
+	if err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to update pay period ID=%d: %s", check.ID, err),
+		}
+	}
+	if affected == 0 {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("Unable to find pay period ID=%d", check.ID),
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Response{
+			Status:  "ERROR",
+			Message: fmt.Sprintf("error creating pay stub: %v", err),
+		}
+	}
+
+	log.Printf("Created pay stub ID: %d for pay period ID: %d\n", newID, check.ID)
+	return Response{
+		Status:  "OK",
+		Message: "New pay stub created:",
+		Data:    json.RawMessage(fmt.Sprintf(`{"stub_id": %d, "period_id": %d}`, newID, check.ID)),
+	}
+}
+
+// GetPayStubs lists uploaded stubs with their earnings, newest pay date
+// first.
+func (database *Database) GetPayStubs() ([]PayStub, error) {
+	rows, err := database.Query(`
+		SELECT id, pay_period_id, pay_date, period_start, period_end, gross, net,
+		       COALESCE(total_hours, 0), filename, COALESCE(uploaded_at, '')
+		FROM pay_stubs
+		ORDER BY pay_date DESC, id DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pay stubs: %w", err)
+	}
+	defer rows.Close()
+
+	stubs := []PayStub{}
+	index := map[int]int{}
+	for rows.Next() {
+		stub := PayStub{Earnings: []PayStubEarning{}}
+		err := rows.Scan(&stub.ID, &stub.PayPeriodID, &stub.PayDate, &stub.PeriodStart, &stub.PeriodEnd,
+			&stub.Gross, &stub.Net, &stub.TotalHours, &stub.Filename, &stub.UploadedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pay stub: %w", err)
+		}
+		for _, date := range []*string{stub.PayDate, stub.PeriodStart, stub.PeriodEnd} {
+			if date != nil {
+				*date = strings.Split(*date, "T")[0]
+			}
+		}
+		index[stub.ID] = len(stubs)
+		stubs = append(stubs, stub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	earnings, err := database.Query(`
+		SELECT stub_id, code, hours, rate, amount
+		FROM pay_stub_earnings
+		ORDER BY stub_id, id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pay stub earnings: %w", err)
+	}
+	defer earnings.Close()
+
+	for earnings.Next() {
+		var stubID int
+		var earning PayStubEarning
+		if err := earnings.Scan(&stubID, &earning.Code, &earning.Hours, &earning.Rate, &earning.Amount); err != nil {
+			return nil, fmt.Errorf("failed to scan pay stub earnings: %w", err)
+		}
+		if i, ok := index[stubID]; ok {
+			stubs[i].Earnings = append(stubs[i].Earnings, earning)
+		}
+	}
+	return stubs, earnings.Err()
+}
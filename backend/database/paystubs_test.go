@@ -0,0 +1,54 @@
+package database
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatchPayPeriodOnlyReads(t *testing.T) {
+	database := openTestDB(t)
+	period, err := database.CreateNewPayPeriod("2025-03-05")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	countPeriods := func() (count int, current int) {
+		t.Helper()
+		err := database.QueryRow(`
+			SELECT COUNT(*), COUNT(CASE WHEN status = 'current' THEN 1 END) FROM pay_periods
+		`).Scan(&count, &current)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return count, current
+	}
+
+	tests := []struct {
+		name        string
+		periodStart string
+		payDate     string
+		wantID      int
+		wantErr     string
+	}{
+		{"period start inside a period", period.BeginDate, "", period.ID, ""},
+		{"pay date of a period", "", period.PayDate, period.ID, ""},
+		{"period start outside every period", "2031-07-01", "", 0, "no pay period contains 2031-07-01"},
+		{"pay date of no period", "", "2031-07-04", 0, "no pay period is paid on 2031-07-04"},
+		{"neither date", "", "", 0, "neither a period start nor a pay date"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched, err := database.MatchPayPeriod(tt.periodStart, tt.payDate)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("MatchPayPeriod() = %v, want an error containing %q", err, tt.wantErr)
+				}
+			} else if err != nil || matched.ID != tt.wantID {
+				t.Errorf("MatchPayPeriod() = period %d, %v; want period %d", matched.ID, err, tt.wantID)
+			}
+			if count, current := countPeriods(); count != 1 || current != 1 {
+				t.Errorf("pay periods = %d (%d current) after matching, want the one period untouched", count, current)
+			}
+		})
+	}
+}
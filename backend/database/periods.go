@@ -1,7 +1,10 @@
 package database
 
 import (
+	"database/sql"
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 	"time"
 )
@@ -41,7 +44,7 @@ func (db *Database) UpdatePayPeriodStatus() error {
 // GetAllPeriods -
 func (db *Database) GetAllPeriods() ([]Paycheck, error) {
 	query := `
-		SELECT id, start_date, end_date, status
+		SELECT id, start_date, end_date, pay_date, actual_pay_gross, actual_pay_net, status
 		FROM pay_periods
 		ORDER BY start_date DESC
 	`
@@ -55,9 +58,10 @@ func (db *Database) GetAllPeriods() ([]Paycheck, error) {
 
 	for rows.Next() {
 		var id int
-		var startDate, endDate, status string
+		var startDate, endDate, payDate, status string
+		var grossActual, netActual *float64
 
-		err = rows.Scan(&id, &startDate, &endDate, &status)
+		err = rows.Scan(&id, &startDate, &endDate, &payDate, &grossActual, &netActual, &status)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan period: %v", err)
 		}
@@ -74,8 +78,11 @@ func (db *Database) GetAllPeriods() ([]Paycheck, error) {
 			ID:          id,
 			BeginDate:   startDate,
 			EndDate:     endDate,
+			PayDate:     payDate,
 			TotalHours:  &totalHours,
 			GrossEarned: &grossEarned,
+			GrossActual: grossActual,
+			NetActual:   netActual,
 			Status:      status,
 		}
 
@@ -84,6 +91,40 @@ func (db *Database) GetAllPeriods() ([]Paycheck, error) {
 	return periods, nil
 }
 
+// GetPayPeriod - one pay period by ID, with its expected gross and hours
+func (db *Database) GetPayPeriod(id int) (Paycheck, error) {
+	query := `
+		SELECT id, start_date, end_date, pay_date, actual_pay_gross, actual_pay_net,
+		       status, COALESCE(last_updated, '')
+		FROM pay_periods
+		WHERE id = ?
+	`
+	var period Paycheck
+	err := db.QueryRow(query, id).Scan(
+		&period.ID, &period.BeginDate, &period.EndDate, &period.PayDate,
+		&period.GrossActual, &period.NetActual, &period.Status, &period.LastUpdated,
+	)
+	if err == sql.ErrNoRows {
+		return Paycheck{}, fmt.Errorf("no pay period with ID=%d", id)
+	}
+	if err != nil {
+		return Paycheck{}, fmt.Errorf("failed to get pay period: %v", err)
+	}
+	period.BeginDate = strings.Split(period.BeginDate, "T")[0]
+	period.EndDate = strings.Split(period.EndDate, "T")[0]
+	period.PayDate = strings.Split(period.PayDate, "T")[0]
+
+	totals, err := db.CalculatePeriodTotals(period.ID, period.BeginDate, period.EndDate)
+	if err != nil {
+		return Paycheck{}, fmt.Errorf("failed to calculate totals: %v", err)
+	}
+	totalHours := totals["total_hours"].(float64)
+	grossEarned := totals["total_gross"].(float64)
+	period.TotalHours = &totalHours
+	period.GrossEarned = &grossEarned
+	return period, nil
+}
+
 // GetCurrentPayPeriod -
 func (db *Database) GetCurrentPayPeriod(date string) (Paycheck, error) {
 	query := `
@@ -144,19 +185,15 @@ func (db *Database) GetCurrentPayPeriod(date string) (Paycheck, error) {
 	return db.CreateNewPayPeriod(date)
 }
 
-// CreateNewPayPeriod -
-func (db *Database) CreateNewPayPeriod(date string) (Paycheck, error) {
-	parsedDate, err := time.Parse("2006-01-02", date)
-	if err != nil {
-		return Paycheck{}, fmt.Errorf("invalid date format: %v", err)
-	}
-
-	weekday := int(parsedDate.Weekday())
+// PeriodBounds - the bi-weekly pay period (Monday to Sunday two weeks
+// later) containing date
+func PeriodBounds(date time.Time) (time.Time, time.Time) {
+	weekday := int(date.Weekday())
 	if weekday == 0 {
 		weekday = 7
 	}
 	daysToMonday := weekday - 1
-	monday := parsedDate.AddDate(0, 0, -daysToMonday)
+	monday := date.AddDate(0, 0, -daysToMonday)
 	referenceMonday, _ := time.Parse("2006-01-02", "2025-01-06")
 
 	daysDiff := int(monday.Sub(referenceMonday).Hours() / 24)
@@ -167,7 +204,17 @@ func (db *Database) CreateNewPayPeriod(date string) (Paycheck, error) {
 	}
 
 	periodStart := referenceMonday.AddDate(0, 0, biWeeklyPeriod*14)
-	periodEnd := periodStart.AddDate(0, 0, 13)
+	return periodStart, periodStart.AddDate(0, 0, 13)
+}
+
+// CreateNewPayPeriod -
+func (db *Database) CreateNewPayPeriod(date string) (Paycheck, error) {
+	parsedDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return Paycheck{}, fmt.Errorf("invalid date format: %v", err)
+	}
+
+	periodStart, periodEnd := PeriodBounds(parsedDate)
 	payDate := periodEnd.AddDate(0, 0, 3)
 
 	updateQuery := `UPDATE pay_periods SET status = 'past' WHERE status = 'current'`
@@ -233,23 +280,20 @@ func (db *Database) CreateNewPayPeriod(date string) (Paycheck, error) {
 // GetCurrentRates -
 func (db *Database) GetCurrentRates(date string) (PayRate, error) {
 	query := `
-		SELECT effective_date, cfi_rate, admin_rate, last_updated
+		SELECT ` + rateColumns + `
 		FROM pay_rates 
 		WHERE effective_date <= ?
 		ORDER BY effective_date DESC
 		LIMIT 1
 	`
 
-	var rate PayRate
-	err := db.QueryRow(query, date).Scan(
-		&rate.EffectiveDate, &rate.CFIRate, &rate.AdminRate, &rate.LastUpdated,
-	)
-
+	rate, err := scanRate(db.QueryRow(query, date))
 	if err != nil {
 		return PayRate{
-			EffectiveDate: date,
-			CFIRate:       9999.99,
-			AdminRate:     9999.99,
+			EffectiveDate:      date,
+			CFIRate:            9999.99,
+			AdminRate:          9999.99,
+			OvertimeMultiplier: 1.5,
 		}, nil
 	}
 
@@ -258,58 +302,402 @@ func (db *Database) GetCurrentRates(date string) (PayRate, error) {
 
 // CalculatePeriodTotals -
 func (db *Database) CalculatePeriodTotals(periodID int, startDate, endDate string) (map[string]interface{}, error) {
+	totals, err := db.CalculateRangeTotals(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	totals["period_id"] = periodID
+	return totals, nil
+}
+
+// PayCategories are the categories pay is broken down by, in display order.
+var PayCategories = []string{"flight", "ground", "sim", "admin", "rides", "misc", "meeting"}
+
+// CalculateRangeTotals - totals for any inclusive date range, applying each
+// pay rate from its effective date. "all" on either side means unbounded.
+//
+// Pay is built entry by entry: base pay per category (flight, ground, sim,
+// admin, rides, misc, meeting) at the rate in effect on the entry's date,
+// premiums for matching entries, then overtime for each Monday-start week
+// whose hours pass that week's threshold. A week that starts before the
+// range counts its earlier entries toward the threshold, but only overtime
+// worked on days in the range is paid here. Overtime is paid as
+// (multiplier - 1) times the week's average base rate. Rides and meetings
+// earn either credited admin hours or a flat amount each, per the rate in
+// effect.
+func (db *Database) CalculateRangeTotals(startDate, endDate string) (map[string]interface{}, error) {
+	totals, _, err := db.CalculateRangePay(startDate, endDate)
+	return totals, err
+}
+
+// CalculateRangePay is CalculateRangeTotals along with the base pay lines
+// for each entry in the range, so itemized exports add up to the totals.
+func (db *Database) CalculateRangePay(startDate, endDate string) (map[string]interface{}, []EntryPayLine, error) {
 	startDate = strings.Split(startDate, "T")[0]
 	endDate = strings.Split(endDate, "T")[0]
 
-	rates, err := db.GetCurrentRates(startDate)
+	if startDate == "all" || endDate == "all" {
+		firstDate, lastDate, err := db.EntryDateRange()
+		if err != nil {
+			return nil, nil, err
+		}
+		if startDate == "all" {
+			startDate = firstDate
+		}
+		if endDate == "all" {
+			endDate = lastDate
+		}
+	}
+
+	// overtime weeks run Monday to Sunday, so the first one may start
+	// before the range
+	rangeStart, err := time.Parse("2006-01-02", startDate)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get rates: %v", err)
-	}
-	hoursQuery := `
-		SELECT 
-			COALESCE(SUM(flight_hours), 0) as flight_hours,
-			COALESCE(SUM(ground_hours), 0) as ground_hours,
-			COALESCE(SUM(sim_hours), 0) as sim_hours,
-			COALESCE(SUM(admin_hours), 0) as admin_hours,
-			COALESCE(SUM(ride_count), 0) as total_rides
-		FROM pay_entries 
-		WHERE date BETWEEN ? AND ?
-	`
+		return nil, nil, fmt.Errorf("invalid start date %q: %w", startDate, err)
+	}
+	firstMonday, _ := PeriodWeekBounds(rangeStart)
+	weekStart := firstMonday.Format("2006-01-02")
+
+	schedule, err := db.RatesInEffect(weekStart, endDate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get rates: %v", err)
+	}
+	rates := RateOn(schedule, startDate)
+
+	premiums, err := db.GetPremiums(startDate, endDate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entries, err := db.FetchEntries(startDate, endDate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	type workday struct {
+		date, time string
+		hours, pay float64
+		inRange    bool
+	}
+	type week struct {
+		start   string
+		days    []workday
+		hours   float64
+		basePay float64
+	}
+	var weeks []*week
+	weeksByStart := map[string]*week{}
+	addToWeek := func(entry Entry, date string, hours, pay float64, inRange bool) {
+		entryTime, _ := time.Parse("2006-01-02", date)
+		monday, _ := PeriodWeekBounds(entryTime)
+		key := monday.Format("2006-01-02")
+		w, ok := weeksByStart[key]
+		if !ok {
+			w = &week{start: key}
+			weeksByStart[key] = w
+			weeks = append(weeks, w)
+		}
+		w.days = append(w.days, workday{date: date, time: entry.Time, hours: hours, pay: pay, inRange: inRange})
+		w.hours += hours
+		w.basePay += pay
+	}
+
+	if weekStart < startDate {
+		dayBefore := rangeStart.AddDate(0, 0, -1).Format("2006-01-02")
+		earlier, err := db.FetchEntries(weekStart, dayBefore)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, entry := range earlier {
+			date := strings.Split(entry.Date, "T")[0]
+			hours, pay := 0.0, 0.0
+			for _, line := range EntryPay(entry, RateOn(schedule, date), db.Rounding) {
+				hours += line.Hours
+				pay += line.Pay
+			}
+			addToWeek(entry, date, hours, pay, false)
+		}
+	}
 
 	var flightHours, groundHours, simHours, adminHours float64
 	var totalRides int
-	err = db.QueryRow(hoursQuery, startDate, endDate).Scan(
-		&flightHours, &groundHours, &simHours, &adminHours, &totalRides,
-	)
+	var rideHours, premiumPay float64
+	var meetings int
+	var meetingHours float64
+	categories := map[string]*CategoryPay{}
+	for _, category := range PayCategories {
+		categories[category] = &CategoryPay{}
+	}
+	var lines []EntryPayLine
+	premiumLines := map[string]*PremiumLine{}
+	var premiumOrder []string
+
+	for _, entry := range entries {
+		date := strings.Split(entry.Date, "T")[0]
+		rate := RateOn(schedule, date)
+
+		fh, gh, sh, ah := db.Rounding.entryHours(entry)
+		rides := 0
+		if entry.RideCount != nil {
+			rides = *entry.RideCount
+		}
+		if rides > 0 {
+			ah = 0
+		}
+		if entry.Meeting {
+			meetings++
+		}
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to calculate hours: %v", err)
+		entryPay := 0.0
+		earned := EntryPay(entry, rate, db.Rounding)
+		for _, category := range PayCategories {
+			line, ok := earned[category]
+			if !ok {
+				continue
+			}
+			categories[category].Hours += line.Hours
+			categories[category].Count += line.Count
+			categories[category].Pay += line.Pay
+			entryPay += line.Pay
+			lines = append(lines, EntryPayLine{Entry: entry, Category: category, CategoryPay: line})
+		}
+		rh := earned["rides"].Hours
+		mh := earned["meeting"].Hours
+
+		flightHours += fh
+		groundHours += gh
+		simHours += sh
+		adminHours += ah + rh + mh
+		totalRides += rides
+		rideHours += rh
+		meetingHours += mh
+
+		entryHours := fh + gh + sh + ah + rh + mh
+		for _, premium := range premiums {
+			if !premium.applies(entry) {
+				continue
+			}
+			amount := premium.FlatAmount + premium.HourlyAmount*entryHours
+			premiumPay += amount
+			line, ok := premiumLines[premium.Name]
+			if !ok {
+				line = &PremiumLine{Name: premium.Name}
+				premiumLines[premium.Name] = line
+				premiumOrder = append(premiumOrder, premium.Name)
+			}
+			line.Entries++
+			line.Amount += amount
+		}
+
+		addToWeek(entry, date, entryHours, entryPay, true)
+	}
+
+	// Hours past the threshold are overtime in the order they were worked,
+	// so each overtime hour lands on the day that crossed it.
+	var overtimeHours, overtimePay float64
+	for _, w := range weeks {
+		rate := RateOn(schedule, w.start)
+		if rate.OvertimeThreshold == nil || w.hours <= *rate.OvertimeThreshold || w.hours == 0 {
+			continue
+		}
+		threshold := *rate.OvertimeThreshold
+		sort.SliceStable(w.days, func(i, j int) bool {
+			if w.days[i].date != w.days[j].date {
+				return w.days[i].date < w.days[j].date
+			}
+			return w.days[i].time < w.days[j].time
+		})
+		worked := 0.0
+		for _, day := range w.days {
+			before := worked
+			worked += day.hours
+			if !day.inRange || worked <= threshold {
+				continue
+			}
+			hours := worked - math.Max(before, threshold)
+			overtimeHours += hours
+			overtimePay += hours * (w.basePay / w.hours) * (rate.OvertimeMultiplier - 1)
+		}
 	}
 
-	rideHours := float64(totalRides) * 0.2
-	totalAdminHours := adminHours + rideHours
+	premiumItems := []PremiumLine{}
+	for _, name := range premiumOrder {
+		premiumItems = append(premiumItems, *premiumLines[name])
+	}
 
 	cfiHours := flightHours + groundHours + simHours
-	cfiPay := cfiHours * rates.CFIRate
-	adminPay := totalAdminHours * rates.AdminRate
-	totalGross := cfiPay + adminPay
+	cfiPay := categories["flight"].Pay + categories["ground"].Pay + categories["sim"].Pay
+	adminPay := categories["admin"].Pay + categories["rides"].Pay
+	basePay := 0.0
+	for _, category := range PayCategories {
+		basePay += categories[category].Pay
+	}
+	totalGross := basePay + overtimePay + premiumPay
 
 	return map[string]interface{}{
-		"period_id":    periodID,
-		"flight_hours": flightHours,
-		"ground_hours": groundHours,
-		"sim_hours":    simHours,
-		"admin_hours":  totalAdminHours,
-		"ride_hours":   rideHours,
-		"total_rides":  totalRides,
-		"total_hours":  cfiHours + totalAdminHours,
-		"cfi_hours":    cfiHours,
-		"cfi_rate":     rates.CFIRate,
-		"admin_rate":   rates.AdminRate,
-		"cfi_pay":      cfiPay,
-		"admin_pay":    adminPay,
-		"total_gross":  totalGross,
-	}, nil
+		"start_date":      startDate,
+		"end_date":        endDate,
+		"flight_hours":    flightHours,
+		"ground_hours":    groundHours,
+		"sim_hours":       simHours,
+		"admin_hours":     adminHours,
+		"ride_hours":      rideHours,
+		"total_rides":     totalRides,
+		"meetings":        meetings,
+		"meeting_hours":   meetingHours,
+		"meeting_pay":     categories["meeting"].Pay,
+		"total_hours":     cfiHours + adminHours,
+		"cfi_hours":       cfiHours,
+		"cfi_rate":        rates.CFIRate,
+		"admin_rate":      rates.AdminRate,
+		"cfi_pay":         cfiPay,
+		"admin_pay":       adminPay,
+		"pay_by_category": categories,
+		"base_pay":        basePay,
+		"overtime_hours":  overtimeHours,
+		"overtime_pay":    overtimePay,
+		"premium_pay":     premiumPay,
+		"premiums":        premiumItems,
+		"total_gross":     totalGross,
+	}, lines, nil
+}
+
+// EntryPay breaks one entry's base pay (before premiums and overtime) down
+// by pay category at rate, after rounding its hours. Only categories the
+// entry earns in are present.
+func EntryPay(entry Entry, rate PayRate, rounding Rounding) map[string]CategoryPay {
+	fh, gh, sh, ah := rounding.entryHours(entry)
+	rides := 0
+	if entry.RideCount != nil {
+		rides = *entry.RideCount
+	}
+
+	// Ride credit replaces any admin hours on the entry; the form fills
+	// admin_hours from the ride count for display only.
+	rh, ridePay := 0.0, 0.0
+	if rides > 0 {
+		ah = 0
+		if rate.RideCreditAmount != nil {
+			ridePay = float64(rides) * *rate.RideCreditAmount
+		} else {
+			rh = float64(rides) * rate.RideCredit()
+			ridePay = rh * rate.AdminRate
+		}
+	}
+
+	// A meeting logged without hours is credited the configured
+	// duration; a flat stipend, when set, is paid instead.
+	mh, meetingPay := 0.0, 0.0
+	if entry.Meeting {
+		if rate.MeetingAmount != nil {
+			meetingPay = *rate.MeetingAmount
+		} else if rate.MeetingHours != nil && fh+gh+sh+ah+rh == 0 {
+			mh = *rate.MeetingHours
+			meetingPay = mh * rate.Hourly("meeting")
+		}
+	}
+
+	// misc entries pay all of their hours at the misc rate
+	hoursByCategory := map[string]float64{"flight": fh, "ground": gh, "sim": sh, "admin": ah}
+	if entry.Type == "misc" {
+		hoursByCategory = map[string]float64{"misc": fh + gh + sh + ah}
+	}
+
+	earned := map[string]CategoryPay{}
+	for category, hours := range hoursByCategory {
+		if hours == 0 {
+			continue
+		}
+		earned[category] = CategoryPay{Hours: hours, Count: 1, Pay: hours * rate.Hourly(category)}
+	}
+	if rides > 0 {
+		earned["rides"] = CategoryPay{Hours: rh, Count: rides, Pay: ridePay}
+	}
+	if entry.Meeting {
+		earned["meeting"] = CategoryPay{Hours: mh, Count: 1, Pay: meetingPay}
+	}
+	return earned
+}
+
+// PeriodWeekBounds - the Monday-to-Sunday workweek containing date, which
+// pay periods and overtime are built from
+func PeriodWeekBounds(date time.Time) (time.Time, time.Time) {
+	offset := (int(date.Weekday()) + 6) % 7
+	monday := date.AddDate(0, 0, -offset)
+	return monday, monday.AddDate(0, 0, 6)
+}
+
+func nilFloat(ptr *float64) float64 {
+	if ptr != nil {
+		return *ptr
+	}
+	return 0
+}
+
+// EntryDateRange - first and last entry dates, or today for both when there
+// are no entries
+func (db *Database) EntryDateRange() (string, string, error) {
+	var minDate, maxDate sql.NullString
+	err := db.QueryRow("SELECT MIN(date), MAX(date) FROM pay_entries").Scan(&minDate, &maxDate)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get entry date range: %v", err)
+	}
+	if !minDate.Valid || !maxDate.Valid {
+		today := time.Now().Format("2006-01-02")
+		return today, today, nil
+	}
+	return strings.Split(minDate.String, "T")[0], strings.Split(maxDate.String, "T")[0], nil
+}
+
+// RateOn - the rate in effect on date, from a schedule as returned by
+// RatesInEffect (oldest first)
+func RateOn(schedule []PayRate, date string) PayRate {
+	rate := schedule[0]
+	for _, change := range schedule[1:] {
+		if change.EffectiveDate <= date {
+			rate = change
+		}
+	}
+	return rate
+}
+
+// RatesInEffect - the rate in effect on startDate followed by any that take
+// effect up to endDate
+func (db *Database) RatesInEffect(startDate, endDate string) ([]PayRate, error) {
+	first, err := db.GetCurrentRates(startDate)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := db.rateChangesBetween(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	return append([]PayRate{first}, changes...), nil
+}
+
+// rateChangesBetween - rates taking effect after startDate up to endDate
+func (db *Database) rateChangesBetween(startDate, endDate string) ([]PayRate, error) {
+	query := `
+		SELECT ` + rateColumns + `
+		FROM pay_rates
+		WHERE effective_date > ? AND effective_date <= ?
+		ORDER BY effective_date ASC
+	`
+	rows, err := db.Query(query, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rate changes: %v", err)
+	}
+	defer rows.Close()
+
+	var changes []PayRate
+	for rows.Next() {
+		rate, err := scanRate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan rate: %v", err)
+		}
+		changes = append(changes, rate)
+	}
+	return changes, nil
 }
 
 // UpdatePayPeriodTotals -
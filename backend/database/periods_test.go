@@ -0,0 +1,229 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func hoursOf(value float64) *float64 {
+	return &value
+}
+
+func TestEntryPay(t *testing.T) {
+	flightRate, miscRate := 60.0, 25.0
+	rate := PayRate{CFIRate: 50, AdminRate: 20, FlightRate: &flightRate, MiscRate: &miscRate}
+	meetingHours, meetingAmount, rideAmount := 1.0, 30.0, 5.0
+	rides := 3
+
+	tests := []struct {
+		name  string
+		entry Entry
+		rate  PayRate
+		want  map[string]CategoryPay
+	}{
+		{
+			"flight and ground at their own rates",
+			Entry{Type: "flight", FlightHours: hoursOf(1.5), GroundHours: hoursOf(0.5)},
+			rate,
+			map[string]CategoryPay{
+				"flight": {Hours: 1.5, Count: 1, Pay: 90},
+				"ground": {Hours: 0.5, Count: 1, Pay: 25}, // CFI rate fallback
+			},
+		},
+		{
+			"misc pays every hour at the misc rate",
+			Entry{Type: "misc", GroundHours: hoursOf(1), AdminHours: hoursOf(1)},
+			rate,
+			map[string]CategoryPay{"misc": {Hours: 2, Count: 1, Pay: 50}},
+		},
+		{
+			"rides credit admin hours in place of logged ones",
+			Entry{Type: "admin", AdminHours: hoursOf(0.6), RideCount: &rides},
+			rate,
+			map[string]CategoryPay{"rides": {Hours: 0.6, Count: 3, Pay: 12}},
+		},
+		{
+			"rides at a flat amount",
+			Entry{Type: "admin", RideCount: &rides},
+			PayRate{CFIRate: 50, AdminRate: 20, RideCreditAmount: &rideAmount},
+			map[string]CategoryPay{"rides": {Hours: 0, Count: 3, Pay: 15}},
+		},
+		{
+			"meeting without hours is credited its duration",
+			Entry{Type: "admin", Meeting: true},
+			PayRate{CFIRate: 50, AdminRate: 20, MeetingHours: &meetingHours},
+			map[string]CategoryPay{"meeting": {Hours: 1, Count: 1, Pay: 20}},
+		},
+		{
+			"meeting with hours is paid as logged",
+			Entry{Type: "admin", AdminHours: hoursOf(2), Meeting: true},
+			PayRate{CFIRate: 50, AdminRate: 20, MeetingHours: &meetingHours},
+			map[string]CategoryPay{
+				"admin":   {Hours: 2, Count: 1, Pay: 40},
+				"meeting": {Hours: 0, Count: 1, Pay: 0},
+			},
+		},
+		{
+			"meeting stipend",
+			Entry{Type: "admin", AdminHours: hoursOf(1), Meeting: true},
+			PayRate{CFIRate: 50, AdminRate: 20, MeetingAmount: &meetingAmount},
+			map[string]CategoryPay{
+				"admin":   {Hours: 1, Count: 1, Pay: 20},
+				"meeting": {Hours: 0, Count: 1, Pay: 30},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EntryPay(tt.entry, tt.rate, nil)
+			if len(got) != len(tt.want) {
+				t.Errorf("EntryPay = %+v, want %+v", got, tt.want)
+			}
+			for category, want := range tt.want {
+				line := got[category]
+				if !closeTo(line.Hours, want.Hours) || line.Count != want.Count || !closeTo(line.Pay, want.Pay) {
+					t.Errorf("%s = %+v, want %+v", category, line, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEntryPayRounding(t *testing.T) {
+	rate := PayRate{CFIRate: 50, AdminRate: 20}
+	rounding := Rounding{"flight": {Increment: 6}}
+	got := EntryPay(Entry{Type: "flight", FlightHours: hoursOf(1 + 20.0/60)}, rate, rounding)
+	if line := got["flight"]; !closeTo(line.Hours, 1.3) || !closeTo(line.Pay, 65) {
+		t.Errorf("rounded flight = %+v, want 1.3h paid 65", line)
+	}
+}
+
+// openTestDB connects to a fresh database in a temp directory. Connect reads
+// the schema relative to the backend directory, as the server runs.
+func openTestDB(t *testing.T) *Database {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(".."); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	database, err := Connect(filepath.Join(t.TempDir(), "pay_log.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
+func TestCalculateRangeTotalsOvertime(t *testing.T) {
+	database := openTestDB(t)
+
+	threshold := 40.0
+	response := database.CreatePayRate(PayRate{
+		EffectiveDate: "2025-01-01", CFIRate: 50, AdminRate: 20,
+		OvertimeThreshold: &threshold, OvertimeMultiplier: 1.5,
+	})
+	if response.Status != "OK" {
+		t.Fatal(response.Message)
+	}
+
+	// the week of Monday 2025-03-03: 40 flight hours Monday to Thursday,
+	// then 5 admin hours on Friday past the threshold
+	for _, date := range []string{"2025-03-03", "2025-03-04", "2025-03-05", "2025-03-06"} {
+		response := database.NewEntry(Entry{Type: "flight", Date: date, Time: "08:00", FlightHours: hoursOf(10)})
+		if response.Status != "OK" {
+			t.Fatal(response.Message)
+		}
+	}
+	response = database.NewEntry(Entry{Type: "admin", Date: "2025-03-07", Time: "08:00", AdminHours: hoursOf(5)})
+	if response.Status != "OK" {
+		t.Fatal(response.Message)
+	}
+
+	// 2100 base pay over 45 hours averages 46.67 an hour, so each overtime
+	// hour earns another half of that
+	weekOvertime := 5 * (2100.0 / 45) * 0.5
+
+	tests := []struct {
+		name         string
+		start, end   string
+		wantBase     float64
+		wantOTHours  float64
+		wantOvertime float64
+		wantGross    float64
+	}{
+		{"whole week", "2025-03-03", "2025-03-09", 2100, 5, weekOvertime, 2100 + weekOvertime},
+		{"under the threshold", "2025-03-03", "2025-03-06", 2000, 0, 0, 2000},
+		// earlier days in the week count toward the threshold but aren't paid
+		{"range starting mid-week", "2025-03-05", "2025-03-09", 1100, 5, weekOvertime, 1100 + weekOvertime},
+		{"only the overtime day", "2025-03-07", "2025-03-07", 100, 5, weekOvertime, 100 + weekOvertime},
+		{"next week", "2025-03-10", "2025-03-16", 0, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			totals, err := database.CalculateRangeTotals(tt.start, tt.end)
+			if err != nil {
+				t.Fatal(err)
+			}
+			base := totals["base_pay"].(float64)
+			otHours := totals["overtime_hours"].(float64)
+			overtime := totals["overtime_pay"].(float64)
+			gross := totals["total_gross"].(float64)
+			if !closeTo(base, tt.wantBase) || !closeTo(otHours, tt.wantOTHours) ||
+				!closeTo(overtime, tt.wantOvertime) || !closeTo(gross, tt.wantGross) {
+				t.Errorf("%s..%s = base %.2f, overtime %.2fh %.2f, gross %.2f; want base %.2f, overtime %.2fh %.2f, gross %.2f",
+					tt.start, tt.end, base, otHours, overtime, gross,
+					tt.wantBase, tt.wantOTHours, tt.wantOvertime, tt.wantGross)
+			}
+		})
+	}
+}
+
+func TestCalculateRangePayLinesMatchTotals(t *testing.T) {
+	database := openTestDB(t)
+
+	response := database.CreatePayRate(PayRate{EffectiveDate: "2025-01-01", CFIRate: 50, AdminRate: 20})
+	if response.Status != "OK" {
+		t.Fatal(response.Message)
+	}
+	// a raise mid-range is paid from its effective date
+	response = database.CreatePayRate(PayRate{EffectiveDate: "2025-03-05", CFIRate: 60, AdminRate: 20})
+	if response.Status != "OK" {
+		t.Fatal(response.Message)
+	}
+
+	rides := 2
+	for _, entry := range []Entry{
+		{Type: "flight", Date: "2025-03-04", Time: "09:00", FlightHours: hoursOf(2), GroundHours: hoursOf(1)},
+		{Type: "flight", Date: "2025-03-06", Time: "09:00", FlightHours: hoursOf(1)},
+		{Type: "admin", Date: "2025-03-06", Time: "13:00", RideCount: &rides},
+	} {
+		if response := database.NewEntry(entry); response.Status != "OK" {
+			t.Fatal(response.Message)
+		}
+	}
+
+	totals, lines, err := database.CalculateRangePay("2025-03-03", "2025-03-09")
+	if err != nil {
+		t.Fatal(err)
+	}
+	linePay := 0.0
+	for _, line := range lines {
+		linePay += line.Pay
+	}
+	// 3h at 50, 1h at 60, two rides at 0.2h of admin
+	want := 150 + 60 + 0.4*20
+	if base := totals["base_pay"].(float64); !closeTo(base, want) || !closeTo(linePay, want) {
+		t.Errorf("base pay %.2f, lines add to %.2f; want %.2f", base, linePay, want)
+	}
+	if len(lines) != 4 {
+		t.Errorf("got %d pay lines, want 4", len(lines))
+	}
+}
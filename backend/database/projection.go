@@ -0,0 +1,158 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultPaceDays is how far back ProjectRange looks to measure pacing.
+const DefaultPaceDays = 28
+
+// ProjectRange projects totals for [beginDate, endDate] as of asOf:
+//
+//	projected = earned through asOf
+//	          + entries already logged for days after asOf
+//	          + open scheduled lessons after asOf, at planned hours
+//	          + recent daily pace * remaining days with nothing logged
+//	            or scheduled
+//
+// Lessons are priced at their type's rate in effect on the lesson date,
+// before rounding, premiums and overtime, as lost income is.
+//
+// Pace is gross and hours per calendar day over the paceDays ending at asOf,
+// shortened if the log starts later than that. A non-nil target is
+// compared against the projection.
+func (db *Database) ProjectRange(scope, beginDate, endDate, asOf string, paceDays int, target *float64) (Projection, error) {
+	beginDate = strings.Split(beginDate, "T")[0]
+	endDate = strings.Split(endDate, "T")[0]
+	asOf = strings.Split(asOf, "T")[0]
+
+	asOfTime, err := time.Parse("2006-01-02", asOf)
+	if err != nil {
+		return Projection{}, fmt.Errorf("invalid date format: %v", err)
+	}
+	endTime, err := time.Parse("2006-01-02", endDate)
+	if err != nil {
+		return Projection{}, fmt.Errorf("invalid date format: %v", err)
+	}
+	if paceDays <= 0 {
+		paceDays = DefaultPaceDays
+	}
+
+	projection := Projection{
+		Scope:       scope,
+		BeginDate:   beginDate,
+		EndDate:     endDate,
+		AsOf:        asOf,
+		TargetGross: target,
+	}
+
+	if beginDate <= asOf {
+		earned, err := db.CalculateRangeTotals(beginDate, earlierOf(asOf, endDate))
+		if err != nil {
+			return Projection{}, err
+		}
+		projection.EarnedGross = earned["total_gross"].(float64)
+		projection.EarnedHours = earned["total_hours"].(float64)
+	}
+
+	if asOf < endDate {
+		scheduledFrom := asOfTime.AddDate(0, 0, 1).Format("2006-01-02")
+		if scheduledFrom < beginDate {
+			scheduledFrom = beginDate
+		}
+		scheduled, err := db.CalculateRangeTotals(scheduledFrom, endDate)
+		if err != nil {
+			return Projection{}, err
+		}
+		projection.ScheduledGross = scheduled["total_gross"].(float64)
+		projection.ScheduledHours = scheduled["total_hours"].(float64)
+
+		lessons, err := db.GetLessons(scheduledFrom, endDate, "scheduled")
+		if err != nil {
+			return Projection{}, err
+		}
+		schedule, err := db.RatesInEffect(scheduledFrom, endDate)
+		if err != nil {
+			return Projection{}, fmt.Errorf("failed to get rates: %v", err)
+		}
+		for _, lesson := range lessons {
+			projection.Lessons++
+			projection.LessonHours += lesson.PlannedHours
+			projection.LessonGross += lesson.PlannedHours * RateOn(schedule, lesson.Date).Hourly(lesson.Type)
+		}
+
+		err = db.QueryRow(`
+			SELECT COUNT(*) FROM (
+				SELECT date(date) FROM pay_entries WHERE date BETWEEN ? AND ?
+				UNION
+				SELECT date(date) FROM scheduled_lessons
+				WHERE status = 'scheduled' AND date BETWEEN ? AND ?
+			)`,
+			scheduledFrom, endDate, scheduledFrom, endDate,
+		).Scan(&projection.ScheduledDays)
+		if err != nil {
+			return Projection{}, fmt.Errorf("failed to count scheduled days: %v", err)
+		}
+
+		fromTime, _ := time.Parse("2006-01-02", scheduledFrom)
+		projection.RemainingDays = int(endTime.Sub(fromTime).Hours()/24) + 1
+	}
+
+	firstDate, _, err := db.EntryDateRange()
+	if err != nil {
+		return Projection{}, err
+	}
+	paceFrom := asOfTime.AddDate(0, 0, -(paceDays - 1)).Format("2006-01-02")
+	if firstDate > paceFrom {
+		paceFrom = firstDate
+	}
+	if paceFrom <= asOf {
+		paceTotals, err := db.CalculateRangeTotals(paceFrom, asOf)
+		if err != nil {
+			return Projection{}, err
+		}
+		paceFromTime, _ := time.Parse("2006-01-02", paceFrom)
+		projection.PaceFrom = paceFrom
+		projection.PaceDays = int(asOfTime.Sub(paceFromTime).Hours()/24) + 1
+		projection.PaceGrossPerDay = paceTotals["total_gross"].(float64) / float64(projection.PaceDays)
+		projection.PaceHoursPerDay = paceTotals["total_hours"].(float64) / float64(projection.PaceDays)
+	}
+
+	openDays := float64(projection.RemainingDays - projection.ScheduledDays)
+	projection.ProjectedGross = projection.EarnedGross + projection.ScheduledGross +
+		projection.LessonGross + projection.PaceGrossPerDay*openDays
+	projection.ProjectedHours = projection.EarnedHours + projection.ScheduledHours +
+		projection.LessonHours + projection.PaceHoursPerDay*openDays
+
+	if target != nil {
+		gap := projection.ProjectedGross - *target
+		projection.TargetGap = &gap
+	}
+	return projection, nil
+}
+
+// ProjectPeriodAndYear projects the pay period and calendar year containing
+// asOf.
+func (db *Database) ProjectPeriodAndYear(asOf string, paceDays int, periodTarget, yearTarget *float64) (Projection, Projection, error) {
+	asOfTime, err := time.Parse("2006-01-02", strings.Split(asOf, "T")[0])
+	if err != nil {
+		return Projection{}, Projection{}, fmt.Errorf("invalid date format: %v", err)
+	}
+
+	periodStart, periodEnd := PeriodBounds(asOfTime)
+	period, err := db.ProjectRange("period", periodStart.Format("2006-01-02"),
+		periodEnd.Format("2006-01-02"), asOf, paceDays, periodTarget)
+	if err != nil {
+		return Projection{}, Projection{}, err
+	}
+
+	yearStart := time.Date(asOfTime.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	year, err := db.ProjectRange("year", yearStart.Format("2006-01-02"),
+		yearStart.AddDate(1, 0, -1).Format("2006-01-02"), asOf, paceDays, yearTarget)
+	if err != nil {
+		return Projection{}, Projection{}, err
+	}
+	return period, year, nil
+}
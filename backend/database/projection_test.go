@@ -0,0 +1,63 @@
+package database
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestProjectRangeCountsScheduledLessons(t *testing.T) {
+	database := openTestDB(t)
+	if response := database.CreatePayRate(PayRate{EffectiveDate: "2025-01-01", CFIRate: 50, AdminRate: 20}); response.Status != "OK" {
+		t.Fatal(response.Message)
+	}
+
+	for _, entry := range []Entry{
+		{Type: "flight", Date: "2025-03-10", Time: "08:00", FlightHours: hoursOf(2)}, // earned
+		{Type: "ground", Date: "2025-03-12", Time: "08:00", GroundHours: hoursOf(1)}, // logged ahead
+	} {
+		if response := database.NewEntry(entry); response.Status != "OK" {
+			t.Fatal(response.Message)
+		}
+	}
+
+	var cancelled int
+	for _, lesson := range []ScheduledLesson{
+		{Date: "2025-03-12", Time: "13:00", Type: "sim", PlannedHours: 1},
+		{Date: "2025-03-13", Time: "09:00", Type: "flight", PlannedHours: 1.5},
+		{Date: "2025-03-14", Time: "09:00", Type: "ground", PlannedHours: 1},
+	} {
+		response := database.CreateLesson(lesson)
+		if response.Status != "OK" {
+			t.Fatal(response.Message)
+		}
+		var created struct {
+			LessonID int `json:"lesson_id"`
+		}
+		json.Unmarshal(response.Data, &created)
+		cancelled = created.LessonID
+	}
+	if response := database.SetLessonStatus(cancelled, "cancelled"); response.Status != "OK" {
+		t.Fatal(response.Message)
+	}
+
+	projection, err := database.ProjectRange("week", "2025-03-10", "2025-03-16", "2025-03-10", 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the cancelled lesson is left out; the sim lesson shares a day with
+	// the logged ground entry, so 4 of the 6 remaining days run at pace
+	if projection.Lessons != 2 || !closeTo(projection.LessonHours, 2.5) || !closeTo(projection.LessonGross, 125) {
+		t.Errorf("lessons = %d, %.2fh, %.2f; want 2, 2.5h, 125",
+			projection.Lessons, projection.LessonHours, projection.LessonGross)
+	}
+	if projection.RemainingDays != 6 || projection.ScheduledDays != 2 {
+		t.Errorf("remaining %d days, %d scheduled; want 6 and 2", projection.RemainingDays, projection.ScheduledDays)
+	}
+	wantGross := 100 + 50 + 125 + 4*100.0
+	wantHours := 2 + 1 + 2.5 + 4*2.0
+	if !closeTo(projection.ProjectedGross, wantGross) || !closeTo(projection.ProjectedHours, wantHours) {
+		t.Errorf("projected %.2f over %.2fh, want %.2f over %.2fh",
+			projection.ProjectedGross, projection.ProjectedHours, wantGross, wantHours)
+	}
+}
@@ -1,17 +1,33 @@
-CREATE TABLE pay_entries (
+CREATE TABLE IF NOT EXISTS pay_entries (
     id INTEGER PRIMARY KEY,
     pay_period_id INTEGER,
     type TEXT NOT NULL, -- flight/ground/sim/admin/misc
     date DATE NOT NULL,
     time TIME,
+    -- hours are kept exact (1:20 is 1.3333...); rounding happens at pay time
     flight_hours DECIMAL(4,2) DEFAULT NULL,
     ground_hours DECIMAL(4,2) DEFAULT NULL,
     sim_hours DECIMAL(4,2) DEFAULT NULL,
     admin_hours DECIMAL(4,2) DEFAULT NULL,
     customer TEXT,
+    customer_id INTEGER REFERENCES customers(id),
     notes TEXT,
     ride_count INTEGER DEFAULT NULL,
     meeting BOOLEAN DEFAULT FALSE,
+    -- logbook fields, flight entries only
+    tail_number TEXT,
+    aircraft_type TEXT,
+    departure TEXT,
+    arrival TEXT,
+    day_landings INTEGER,
+    night_landings INTEGER,
+    night_hours DECIMAL(4,2),
+    ifr_hours DECIMAL(4,2),
+    xc_hours DECIMAL(4,2),
+    hobbs_start DECIMAL(8,1), -- flight_hours is derived from hobbs when both are set
+    hobbs_end DECIMAL(8,1),
+    tach_start DECIMAL(8,1),
+    tach_end DECIMAL(8,1),
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
@@ -20,6 +36,16 @@ CREATE TABLE IF NOT EXISTS pay_rates (
     effective_date DATE NOT NULL,
     cfi_rate DECIMAL(6,2) NOT NULL,
     admin_rate DECIMAL(6,2) NOT NULL,
+    flight_rate DECIMAL(6,2) DEFAULT NULL, -- NULL pays cfi_rate
+    ground_rate DECIMAL(6,2) DEFAULT NULL, -- NULL pays cfi_rate
+    sim_rate DECIMAL(6,2) DEFAULT NULL, -- NULL pays cfi_rate
+    misc_rate DECIMAL(6,2) DEFAULT NULL, -- NULL pays admin_rate
+    meeting_amount DECIMAL(6,2) DEFAULT NULL, -- flat per meeting entry
+    meeting_hours DECIMAL(4,2) DEFAULT NULL, -- admin hours credited per meeting logged without hours
+    ride_credit_hours DECIMAL(4,2) DEFAULT 0.2, -- admin hours credited per ride
+    ride_credit_amount DECIMAL(6,2) DEFAULT NULL, -- flat pay per ride; replaces hours when set
+    overtime_threshold DECIMAL(5,2) DEFAULT NULL, -- weekly hours; NULL for no overtime
+    overtime_multiplier DECIMAL(4,2) DEFAULT 1.5,
     last_updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
@@ -44,8 +70,117 @@ CREATE TABLE IF NOT EXISTS monthly_stats (
     month INTEGER NOT NULL,
     total_flight_hours DECIMAL(6,2),
     total_ground_hours DECIMAL(6,2),
+    total_sim_hours DECIMAL(6,2),
     total_admin_hours DECIMAL(6,2),
     total_gross_pay DECIMAL(8,2),
     last_updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     UNIQUE(year, month)
-);
\ No newline at end of file
+);
+
+CREATE TABLE IF NOT EXISTS customers (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS customer_aliases (
+    id INTEGER PRIMARY KEY,
+    customer_id INTEGER NOT NULL REFERENCES customers(id),
+    alias TEXT NOT NULL UNIQUE COLLATE NOCASE
+);
+
+CREATE TABLE IF NOT EXISTS income_goals (
+    id INTEGER PRIMARY KEY,
+    scope TEXT NOT NULL, -- period/month/year
+    metric TEXT NOT NULL, -- gross/hours
+    target DECIMAL(8,2) NOT NULL,
+    effective_date DATE NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS pay_premiums (
+    id INTEGER PRIMARY KEY,
+    effective_date DATE NOT NULL,
+    end_date DATE DEFAULT NULL,
+    name TEXT NOT NULL,
+    entry_type TEXT DEFAULT NULL, -- only entries of this type; NULL for any
+    keyword TEXT DEFAULT NULL, -- only entries whose notes contain this; NULL for any
+    flat_amount DECIMAL(6,2) DEFAULT 0,
+    hourly_amount DECIMAL(6,2) DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS scheduled_lessons (
+    id INTEGER PRIMARY KEY,
+    date DATE NOT NULL,
+    time TEXT,
+    type TEXT NOT NULL, -- flight/ground/sim
+    planned_hours DECIMAL(4,2) NOT NULL,
+    customer TEXT,
+    customer_id INTEGER REFERENCES customers(id),
+    status TEXT NOT NULL DEFAULT 'scheduled', -- scheduled/completed/cancelled/no_show
+    entry_id INTEGER REFERENCES pay_entries(id), -- set once completed
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS draft_entries (
+    id INTEGER PRIMARY KEY,
+    date DATE NOT NULL,
+    time TEXT,
+    type TEXT NOT NULL,
+    hours DECIMAL(4,2),
+    customer TEXT,
+    notes TEXT,
+    meeting BOOLEAN DEFAULT FALSE,
+    source_uid TEXT NOT NULL UNIQUE, -- calendar event UID, so re-imports skip it
+    status TEXT NOT NULL DEFAULT 'pending', -- pending/confirmed/discarded
+    entry_id INTEGER REFERENCES pay_entries(id), -- set once confirmed
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS aircraft (
+    id INTEGER PRIMARY KEY,
+    tail_number TEXT NOT NULL UNIQUE COLLATE NOCASE,
+    make_model TEXT,
+    category TEXT, -- airplane/rotorcraft/glider/sim...
+    hourly_rate DECIMAL(6,2) DEFAULT NULL,
+    active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS credentials (
+    id INTEGER PRIMARY KEY,
+    kind TEXT NOT NULL, -- certificate/rating/cfi/flight_review/medical/other
+    name TEXT NOT NULL,
+    number TEXT,
+    issued_date DATE,
+    expires_date DATE, -- NULL never expires; computed for cfi/flight_review when omitted
+    medical_class INTEGER,
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS pay_stubs (
+    id INTEGER PRIMARY KEY,
+    pay_period_id INTEGER REFERENCES pay_periods(id),
+    pay_date DATE,
+    period_start DATE,
+    period_end DATE,
+    gross DECIMAL(8,2),
+    net DECIMAL(8,2),
+    total_hours DECIMAL(6,2),
+    filename TEXT,
+    raw_text TEXT, -- extracted text, kept so patterns can be fixed and re-run
+    uploaded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS pay_stub_earnings (
+    id INTEGER PRIMARY KEY,
+    stub_id INTEGER NOT NULL REFERENCES pay_stubs(id) ON DELETE CASCADE,
+    code TEXT NOT NULL, -- earnings code as printed on the stub
+    hours DECIMAL(6,2),
+    rate DECIMAL(6,2),
+    amount DECIMAL(8,2) NOT NULL
+);
@@ -0,0 +1,240 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// UpdateMonthlyStats recalculates the monthly_stats row for one month from
+// pay_entries, using the same totals engine as pay periods.
+func (db *Database) UpdateMonthlyStats(year, month int) error {
+	totals, err := db.monthTotals(year, month)
+	if err != nil {
+		return err
+	}
+	return writeMonthlyStats(db, year, month, totals)
+}
+
+func (db *Database) monthTotals(year, month int) (map[string]interface{}, error) {
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, -1)
+
+	totals, err := db.CalculateRangeTotals(start.Format("2006-01-02"), end.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate monthly totals: %v", err)
+	}
+	return totals, nil
+}
+
+// writeMonthlyStats stores one month's totals through exec.
+func writeMonthlyStats(exec execer, year, month int, totals map[string]interface{}) error {
+	query := `
+		INSERT INTO monthly_stats (
+			year, month, total_flight_hours, total_ground_hours, total_sim_hours,
+			total_admin_hours, total_gross_pay, last_updated
+		) VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(year, month) DO UPDATE SET
+			total_flight_hours = excluded.total_flight_hours,
+			total_ground_hours = excluded.total_ground_hours,
+			total_sim_hours = excluded.total_sim_hours,
+			total_admin_hours = excluded.total_admin_hours,
+			total_gross_pay = excluded.total_gross_pay,
+			last_updated = CURRENT_TIMESTAMP
+	`
+	_, err := exec.Exec(query, year, month,
+		totals["flight_hours"], totals["ground_hours"], totals["sim_hours"],
+		totals["admin_hours"], totals["total_gross"],
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update monthly stats: %v", err)
+	}
+	return nil
+}
+
+// updateMonthlyStatsFor refreshes the month containing date. Entry handlers
+// call it after every mutation; failures are logged, not returned, in the
+// same way as pay period totals.
+func (db *Database) updateMonthlyStatsFor(date string) {
+	parsed, err := time.Parse("2006-01-02", strings.Split(date, "T")[0])
+	if err != nil {
+		log.Printf("Warning: failed to update monthly stats, bad date %q: %v", date, err)
+		return
+	}
+	if err := db.UpdateMonthlyStats(parsed.Year(), int(parsed.Month())); err != nil {
+		log.Printf("Warning: failed to update monthly stats: %v", err)
+	}
+}
+
+// RebuildMonthlyStats discards monthly_stats and recalculates every month
+// that has entries. The months are totalled first and then replaced in one
+// transaction, so a failure leaves the old rows in place.
+func (db *Database) RebuildMonthlyStats() (int, error) {
+	rows, err := db.Query(`
+		SELECT DISTINCT CAST(strftime('%Y', date) AS INTEGER), CAST(strftime('%m', date) AS INTEGER)
+		FROM pay_entries
+		ORDER BY 1, 2
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get entry months: %v", err)
+	}
+	var months [][2]int
+	for rows.Next() {
+		var year, month int
+		if err := rows.Scan(&year, &month); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan month: %v", err)
+		}
+		months = append(months, [2]int{year, month})
+	}
+	rows.Close()
+
+	monthTotals := make([]map[string]interface{}, len(months))
+	for i, ym := range months {
+		if monthTotals[i], err = db.monthTotals(ym[0], ym[1]); err != nil {
+			return 0, err
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start rebuild: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM monthly_stats"); err != nil {
+		return 0, fmt.Errorf("failed to clear monthly stats: %v", err)
+	}
+	for i, ym := range months {
+		if err := writeMonthlyStats(tx, ym[0], ym[1], monthTotals[i]); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to save monthly stats: %v", err)
+	}
+	return len(months), nil
+}
+
+// GetMonthlyStats returns the stored rollups for a year, oldest month first.
+func (db *Database) GetMonthlyStats(year int) ([]MonthlyStats, error) {
+	query := `
+		SELECT year, month,
+		       COALESCE(total_flight_hours, 0), COALESCE(total_ground_hours, 0),
+		       COALESCE(total_sim_hours, 0), COALESCE(total_admin_hours, 0),
+		       COALESCE(total_gross_pay, 0), last_updated
+		FROM monthly_stats
+		WHERE year = ?
+		ORDER BY month ASC
+	`
+	rows, err := db.Query(query, year)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get monthly stats: %v", err)
+	}
+	defer rows.Close()
+
+	stats := []MonthlyStats{}
+	for rows.Next() {
+		var month MonthlyStats
+		err = rows.Scan(&month.Year, &month.Month, &month.FlightHours, &month.GroundHours,
+			&month.SimHours, &month.AdminHours, &month.GrossPay, &month.LastUpdated)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan monthly stats: %v", err)
+		}
+		stats = append(stats, month)
+	}
+	return stats, nil
+}
+
+// GetYearSummary totals every pay period paid in year that had started by
+// asOf. The year is a pay-date basis throughout, matching the year-to-date
+// figures on pay stubs: a period's hours and gross count toward the year it
+// is paid in, even days worked in the December before, so these totals
+// differ from the calendar-month stats around New Year. A period still open
+// on asOf is counted through asOf but is not picked as best or worst.
+func (db *Database) GetYearSummary(year int, asOf string) (YearSummary, error) {
+	asOf = strings.Split(asOf, "T")[0]
+	query := `
+		SELECT id, start_date, end_date, pay_date, actual_pay_gross, actual_pay_net
+		FROM pay_periods
+		WHERE strftime('%Y', pay_date) = ? AND start_date <= ?
+		ORDER BY start_date ASC
+	`
+	rows, err := db.Query(query, fmt.Sprintf("%04d", year), asOf)
+	if err != nil {
+		return YearSummary{}, fmt.Errorf("failed to get periods: %v", err)
+	}
+	var periods []PeriodSummary
+	for rows.Next() {
+		var period PeriodSummary
+		err = rows.Scan(&period.ID, &period.BeginDate, &period.EndDate, &period.PayDate,
+			&period.ActualGross, &period.ActualNet)
+		if err != nil {
+			rows.Close()
+			return YearSummary{}, fmt.Errorf("failed to scan period: %v", err)
+		}
+		period.BeginDate = strings.Split(period.BeginDate, "T")[0]
+		period.EndDate = strings.Split(period.EndDate, "T")[0]
+		period.PayDate = strings.Split(period.PayDate, "T")[0]
+		periods = append(periods, period)
+	}
+	rows.Close()
+
+	summary := YearSummary{
+		Year:         year,
+		AsOf:         asOf,
+		PeriodDetail: []PeriodSummary{},
+	}
+	for _, period := range periods {
+		totals, err := db.CalculatePeriodTotals(period.ID, period.BeginDate, earlierOf(period.EndDate, asOf))
+		if err != nil {
+			return YearSummary{}, err
+		}
+
+		period.TotalHours = totals["total_hours"].(float64)
+		period.ExpectedGross = totals["total_gross"].(float64)
+		period.Open = period.EndDate > asOf
+
+		summary.FlightHours += totals["flight_hours"].(float64)
+		summary.GroundHours += totals["ground_hours"].(float64)
+		summary.SimHours += totals["sim_hours"].(float64)
+		summary.AdminHours += totals["admin_hours"].(float64)
+		summary.TotalHours += period.TotalHours
+		summary.ExpectedGross += period.ExpectedGross
+		if period.ActualGross != nil {
+			summary.ActualGross += *period.ActualGross
+			summary.PeriodsWithActuals++
+		}
+		if period.ActualNet != nil {
+			summary.ActualNet += *period.ActualNet
+		}
+		summary.PeriodDetail = append(summary.PeriodDetail, period)
+	}
+
+	summary.Periods = len(summary.PeriodDetail)
+	if summary.Periods > 0 {
+		summary.AvgHoursPerPeriod = summary.TotalHours / float64(summary.Periods)
+	}
+	for i := range summary.PeriodDetail {
+		period := &summary.PeriodDetail[i]
+		if period.Open {
+			continue
+		}
+		if summary.BestPeriod == nil || period.ExpectedGross > summary.BestPeriod.ExpectedGross {
+			summary.BestPeriod = period
+		}
+		if summary.WorstPeriod == nil || period.ExpectedGross < summary.WorstPeriod.ExpectedGross {
+			summary.WorstPeriod = period
+		}
+	}
+	return summary, nil
+}
+
+func earlierOf(a, b string) string {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,51 @@
+package database
+
+import "testing"
+
+func TestRebuildMonthlyStats(t *testing.T) {
+	database := openTestDB(t)
+	if response := database.CreatePayRate(PayRate{EffectiveDate: "2025-01-01", CFIRate: 50, AdminRate: 20}); response.Status != "OK" {
+		t.Fatal(response.Message)
+	}
+	for _, entry := range []Entry{
+		{Type: "flight", Date: "2025-01-15", Time: "08:00", FlightHours: hoursOf(2)},
+		{Type: "admin", Date: "2025-02-03", Time: "08:00", AdminHours: hoursOf(1)},
+	} {
+		if response := database.NewEntry(entry); response.Status != "OK" {
+			t.Fatal(response.Message)
+		}
+	}
+	// a leftover row for a month without entries is discarded
+	if _, err := database.Exec("INSERT INTO monthly_stats (year, month, total_gross_pay) VALUES (2024, 6, 99)"); err != nil {
+		t.Fatal(err)
+	}
+
+	months, err := database.RebuildMonthlyStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if months != 2 {
+		t.Errorf("rebuilt %d months, want 2", months)
+	}
+	if stale, _ := database.GetMonthlyStats(2024); len(stale) != 0 {
+		t.Errorf("2024 stats = %+v, want the stale row gone", stale)
+	}
+	stats, err := database.GetMonthlyStats(2025)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 2 || stats[0].GrossPay != 100 || stats[1].GrossPay != 20 {
+		t.Errorf("2025 stats = %+v, want January 100 and February 20", stats)
+	}
+
+	// a failed rebuild leaves the existing rows alone
+	if _, err := database.Exec("DROP TABLE pay_rates"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.RebuildMonthlyStats(); err == nil {
+		t.Fatal("want an error rebuilding without pay rates")
+	}
+	if kept, _ := database.GetMonthlyStats(2025); len(kept) != 2 {
+		t.Errorf("after a failed rebuild 2025 stats = %+v, want both months kept", kept)
+	}
+}
@@ -9,6 +9,13 @@ func nilCheck[T any](ptr *T) any {
 	return nil
 }
 
+// keepIfNil fills an omitted field from its stored value.
+func keepIfNil[T any](field **T, stored *T) {
+	if *field == nil {
+		*field = stored
+	}
+}
+
 func getTableName(errorMsg string) string {
 	if strings.Contains(errorMsg, "table") && strings.Contains(errorMsg, "already exists") {
 		parts := strings.Fields(errorMsg)
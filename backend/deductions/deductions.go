@@ -0,0 +1,148 @@
+// Package deductions estimates net pay from gross using a configurable
+// model of flat-percentage, fixed and federal bracket withholding.
+package deductions
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Flat is a percentage of gross. FICA percentages (social security and
+// medicare) are taken of FICA wages instead: gross less the fixed
+// deductions marked fica_exempt.
+type Flat struct {
+	Name    string  `yaml:"name" json:"name"`
+	Percent float64 `yaml:"percent" json:"percent"`
+	FICA    bool    `yaml:"fica" json:"fica"`
+}
+
+// Fixed is a per-period amount such as health insurance. Pre-tax amounts
+// reduce the wages federal withholding is figured on; FICA-exempt ones
+// (a cafeteria plan, but not a 401(k)) also reduce FICA wages.
+type Fixed struct {
+	Name       string  `yaml:"name" json:"name"`
+	Amount     float64 `yaml:"amount" json:"amount"`
+	PreTax     bool    `yaml:"pre_tax" json:"pre_tax"`
+	FICAExempt bool    `yaml:"fica_exempt" json:"fica_exempt"`
+}
+
+// Bracket taxes annual taxable income above Over at Rate percent.
+type Bracket struct {
+	Over float64 `yaml:"over" json:"over"`
+	Rate float64 `yaml:"rate" json:"rate"`
+}
+
+type Federal struct {
+	StandardDeduction float64   `yaml:"standard_deduction" json:"standard_deduction"`
+	Brackets          []Bracket `yaml:"brackets" json:"brackets"`
+}
+
+type Model struct {
+	PeriodsPerYear int      `yaml:"pay_periods_per_year" json:"pay_periods_per_year"`
+	Flat           []Flat   `yaml:"flat" json:"flat"`
+	Fixed          []Fixed  `yaml:"fixed" json:"fixed"`
+	Federal        *Federal `yaml:"federal" json:"federal,omitempty"`
+}
+
+type Line struct {
+	Name   string  `json:"name"`
+	Amount float64 `json:"amount"`
+}
+
+type Estimate struct {
+	Gross      float64 `json:"gross"`
+	Deductions []Line  `json:"deductions"`
+	Total      float64 `json:"total_deductions"`
+	Net        float64 `json:"net"`
+}
+
+// Load reads a deduction model from a YAML file.
+func Load(path string) (*Model, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read deductions: %w", err)
+	}
+
+	var model Model
+	if err := yaml.Unmarshal(data, &model); err != nil {
+		return nil, fmt.Errorf("failed to parse deductions: %w", err)
+	}
+	if model.PeriodsPerYear <= 0 {
+		model.PeriodsPerYear = 26
+	}
+	if model.Federal != nil {
+		sort.Slice(model.Federal.Brackets, func(i, j int) bool {
+			return model.Federal.Brackets[i].Over < model.Federal.Brackets[j].Over
+		})
+	}
+	return &model, nil
+}
+
+// Estimate applies the model to one pay period's gross. Federal withholding
+// uses the annualized percentage method: gross is scaled to a year, the
+// standard deduction removed, brackets applied, and the tax divided back
+// down to one period. A period with no gross has nothing withheld, and net
+// never goes below zero.
+func (model *Model) Estimate(gross float64) Estimate {
+	estimate := Estimate{Gross: gross, Deductions: []Line{}}
+	if gross <= 0 {
+		return estimate
+	}
+
+	preTax, ficaExempt := 0.0, 0.0
+	for _, fixed := range model.Fixed {
+		estimate.add(fixed.Name, fixed.Amount)
+		if fixed.PreTax {
+			preTax += fixed.Amount
+		}
+		if fixed.PreTax && fixed.FICAExempt {
+			ficaExempt += fixed.Amount
+		}
+	}
+
+	ficaWages := math.Max(gross-ficaExempt, 0)
+	for _, flat := range model.Flat {
+		base := gross
+		if flat.FICA {
+			base = ficaWages
+		}
+		estimate.add(flat.Name, base*flat.Percent/100)
+	}
+
+	if model.Federal != nil {
+		taxable := math.Max(gross-preTax, 0)*float64(model.PeriodsPerYear) - model.Federal.StandardDeduction
+		estimate.add("federal_income_tax", model.Federal.annualTax(taxable)/float64(model.PeriodsPerYear))
+	}
+
+	estimate.Net = round(math.Max(gross-estimate.Total, 0))
+	return estimate
+}
+
+func (estimate *Estimate) add(name string, amount float64) {
+	amount = round(amount)
+	estimate.Deductions = append(estimate.Deductions, Line{Name: name, Amount: amount})
+	estimate.Total = round(estimate.Total + amount)
+}
+
+func (federal *Federal) annualTax(taxable float64) float64 {
+	tax := 0.0
+	for i, bracket := range federal.Brackets {
+		if taxable <= bracket.Over {
+			break
+		}
+		top := taxable
+		if i+1 < len(federal.Brackets) && federal.Brackets[i+1].Over < taxable {
+			top = federal.Brackets[i+1].Over
+		}
+		tax += (top - bracket.Over) * bracket.Rate / 100
+	}
+	return tax
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,107 @@
+package deductions
+
+import "testing"
+
+func testModel() *Model {
+	return &Model{
+		PeriodsPerYear: 26,
+		Flat: []Flat{
+			{Name: "social_security", Percent: 6.2, FICA: true},
+			{Name: "medicare", Percent: 1.45, FICA: true},
+			{Name: "state", Percent: 3},
+		},
+		Fixed: []Fixed{
+			{Name: "health_insurance", Amount: 100, PreTax: true, FICAExempt: true},
+			{Name: "retirement", Amount: 50, PreTax: true},
+		},
+		Federal: &Federal{
+			StandardDeduction: 15000,
+			Brackets:          []Bracket{{Over: 0, Rate: 10}, {Over: 11925, Rate: 12}, {Over: 48475, Rate: 22}},
+		},
+	}
+}
+
+func deduction(estimate Estimate, name string) (float64, bool) {
+	for _, line := range estimate.Deductions {
+		if line.Name == name {
+			return line.Amount, true
+		}
+	}
+	return 0, false
+}
+
+func TestEstimate(t *testing.T) {
+	estimate := testModel().Estimate(2000)
+
+	// FICA is taken of gross less the FICA-exempt health insurance; the
+	// 401(k)-style retirement deduction stays in FICA wages
+	want := map[string]float64{
+		"health_insurance": 100,
+		"retirement":       50,
+		"social_security":  117.80, // 6.2% of 1900
+		"medicare":         27.55,  // 1.45% of 1900
+		"state":            60,     // 3% of gross
+		// (2000 - 150) * 26 = 48100 - 15000 = 33100 taxable:
+		// 1192.50 + (33100 - 11925) * 12% = 3733.50 a year
+		"federal_income_tax": 143.60,
+	}
+	for name, amount := range want {
+		got, ok := deduction(estimate, name)
+		if !ok {
+			t.Errorf("missing %s deduction", name)
+			continue
+		}
+		if got != amount {
+			t.Errorf("%s = %.2f, want %.2f", name, got, amount)
+		}
+	}
+
+	total := 0.0
+	for _, amount := range want {
+		total += amount
+	}
+	if estimate.Total != round(total) {
+		t.Errorf("total = %.2f, want %.2f", estimate.Total, round(total))
+	}
+	if estimate.Net != round(2000-total) {
+		t.Errorf("net = %.2f, want %.2f", estimate.Net, round(2000-total))
+	}
+}
+
+func TestEstimateZeroGross(t *testing.T) {
+	estimate := testModel().Estimate(0)
+	if len(estimate.Deductions) != 0 || estimate.Total != 0 || estimate.Net != 0 {
+		t.Errorf("zero gross = %+v, want nothing withheld", estimate)
+	}
+}
+
+func TestEstimateNetNeverNegative(t *testing.T) {
+	// fixed deductions larger than a short period's gross
+	estimate := testModel().Estimate(120)
+	if estimate.Net != 0 {
+		t.Errorf("net = %.2f, want 0 when deductions exceed gross", estimate.Net)
+	}
+	if estimate.Total < 120 {
+		t.Errorf("total = %.2f, want the fixed deductions still listed", estimate.Total)
+	}
+}
+
+func TestAnnualTax(t *testing.T) {
+	federal := testModel().Federal
+	tests := []struct {
+		taxable float64
+		want    float64
+	}{
+		{-500, 0},
+		{0, 0},
+		{10000, 1000},
+		{11925, 1192.5},
+		{20000, 1192.5 + 8075*0.12},
+		{60000, 1192.5 + 36550*0.12 + 11525*0.22},
+	}
+	for _, tt := range tests {
+		if got := federal.annualTax(tt.taxable); round(got) != round(tt.want) {
+			t.Errorf("annualTax(%v) = %.2f, want %.2f", tt.taxable, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	db "github.com/theHousedev/pay-log/backend/database"
+)
+
+// setupGoals lists goal history (GET, optional ?scope=) or sets a new goal
+// (POST). Setting a goal never edits older ones, so history is preserved.
+func setupGoals(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			goals, err := database.GetGoalHistory(r.URL.Query().Get("scope"))
+			if err != nil {
+				toJSON(w, db.Response{
+					Status:  "ERROR",
+					Message: fmt.Sprintf("Failed to get goals: %v", err),
+				})
+				return
+			}
+
+			data, _ := json.Marshal(goals)
+			toJSON(w, db.Response{
+				Status:  "OK",
+				Message: "Goals retrieved",
+				Data:    data,
+			})
+
+		case http.MethodPost:
+			var goal db.Goal
+			if err := json.NewDecoder(r.Body).Decode(&goal); err != nil {
+				toJSON(w, db.Response{
+					Status:  "ERROR",
+					Message: "Invalid JSON format",
+				})
+				return
+			}
+			toJSON(w, database.CreateGoal(goal))
+
+		default:
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+		}
+	}
+}
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	db "github.com/theHousedev/pay-log/backend/database"
@@ -15,6 +19,42 @@ func toJSON(w http.ResponseWriter, r db.Response) {
 	json.NewEncoder(w).Encode(r)
 }
 
+// attachment sets the headers for a file download.
+func attachment(w http.ResponseWriter, contentType, filename string) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+}
+
+// entryJSONError explains why an entry body could not be decoded, naming
+// the field when an hour value was not a valid duration.
+func entryJSONError(err error) string {
+	var durationErr *db.DurationError
+	if errors.As(err, &durationErr) {
+		return fmt.Sprintf("Invalid %s", durationErr)
+	}
+	return "Invalid JSON format"
+}
+
+func formatHours(hours float64) string {
+	return strconv.FormatFloat(hours, 'f', 2, 64)
+}
+
+// slugify reduces s to lowercase letters, digits and dashes for filenames.
+func slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
 func setupNewEntry(database *db.Database) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -26,7 +66,7 @@ func setupNewEntry(database *db.Database) http.HandlerFunc {
 		if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
 			toJSON(w, db.Response{
 				Status:  "ERROR",
-				Message: "Invalid JSON format",
+				Message: entryJSONError(err),
 			})
 			return
 		}
@@ -62,6 +102,20 @@ func auth(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// feedAuth lets calendar apps, which cannot log in, read a feed with
+// ?token= matching PAYFEED. Anything else must have a session.
+func feedAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := os.Getenv("PAYFEED")
+		given := r.URL.Query().Get("token")
+		if token != "" && given != "" && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1 {
+			next(w, r)
+			return
+		}
+		auth(next)(w, r)
+	}
+}
+
 func getCredentials() (string, string) {
 	return os.Getenv("PAYUN"), os.Getenv("PAYPS")
 }
@@ -108,7 +162,7 @@ func setupEditEntry(database *db.Database) http.HandlerFunc {
 		if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
 			toJSON(w, db.Response{
 				Status:  "ERROR",
-				Message: "Invalid JSON format",
+				Message: entryJSONError(err),
 			})
 			return
 		}
@@ -148,6 +202,12 @@ func setupGetAllPeriods(database *db.Database) http.HandlerFunc {
 			return
 		}
 
+		for i := range periods {
+			if periods[i].GrossEarned != nil {
+				periods[i].NetEstimate = estimateNet(*periods[i].GrossEarned)
+			}
+		}
+
 		data, _ := json.Marshal(periods)
 		toJSON(w, db.Response{
 			Status:  "OK",
@@ -187,9 +247,34 @@ func setupCurrentPeriod(database *db.Database) http.HandlerFunc {
 			return
 		}
 
+		projection, err := database.ProjectRange("period", period.BeginDate, period.EndDate, date, 0, nil)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to project period: %v", err),
+			})
+			return
+		}
+		// remaining is hours, as the form shows it; remaining_gross is the
+		// same projection in dollars
+		totals["remaining"] = projection.ProjectedHours - projection.EarnedHours
+		totals["remaining_gross"] = projection.ProjectedGross - projection.EarnedGross
+		period.NetEstimate = estimateNet(totals["total_gross"].(float64))
+
+		goals, err := database.GetGoalProgress(date)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to get goal progress: %v", err),
+			})
+			return
+		}
+
 		responseData := map[string]interface{}{
-			"period": period,
-			"totals": totals,
+			"period":     period,
+			"totals":     totals,
+			"projection": projection,
+			"goals":      goals,
 		}
 
 		data, _ := json.Marshal(responseData)
@@ -208,49 +293,16 @@ func setupGetEntries(database *db.Database) http.HandlerFunc {
 			return
 		}
 
-		view := r.URL.Query().Get("view")
-		if view == "" {
-			view = "period"
-		}
-
-		date := r.URL.Query().Get("date")
-		if date == "" {
-			date = time.Now().In(time.Local).Format("2006-01-02")
-		}
-
-		var entries []db.Entry
-		var err error
-
-		switch view {
-		case "period":
-			period, err := database.GetCurrentPayPeriod(date)
-			if err != nil {
-				toJSON(w, db.Response{
-					Status:  "ERROR",
-					Message: fmt.Sprintf("Failed to get current period: %v", err),
-				})
-				return
-			}
-			entries, err = database.FetchEntries(period.BeginDate, period.EndDate)
-
-		case "day":
-			entries, err = database.FetchEntries(date, date)
-
-		case "week":
-			startOfWeek, endOfWeek := getCurrentWeek(date)
-			entries, err = database.FetchEntries(startOfWeek, endOfWeek)
-
-		case "all":
-			entries, err = database.FetchEntries("all", "all")
-
-		default:
+		view, filter, err := viewEntryFilter(database, r)
+		if err != nil {
 			toJSON(w, db.Response{
 				Status:  "ERROR",
-				Message: "Invalid view type. Use: period, day, week, or all",
+				Message: err.Error(),
 			})
 			return
 		}
 
+		entries, total, err := database.FetchFilteredEntries(filter)
 		if err != nil {
 			toJSON(w, db.Response{
 				Status:  "ERROR",
@@ -264,59 +316,118 @@ func setupGetEntries(database *db.Database) http.HandlerFunc {
 			Status:  "OK",
 			Message: fmt.Sprintf("Entries retrieved for %s view", view),
 			Data:    data,
+			Pagination: &db.Pagination{
+				Total:  total,
+				Limit:  filter.Limit,
+				Offset: filter.Offset,
+			},
 		})
 	}
 }
 
-func setupGetTotals(database *db.Database) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
-			return
+// viewEntryFilter builds the filter for /api/get-entries and its exports:
+// the request's filter parameters, with dates from the view.
+func viewEntryFilter(database *db.Database, r *http.Request) (string, db.EntryFilter, error) {
+	filter, err := parseEntryFilter(r)
+	if err != nil {
+		return "", filter, err
+	}
+
+	view, beginDate, endDate, err := requestRange(database, r)
+	if err != nil {
+		return view, filter, err
+	}
+	filter.BeginDate, filter.EndDate = beginDate, endDate
+	return view, filter, nil
+}
+
+// parseEntryFilter reads the optional filter, sort and paging parameters
+// accepted by /api/get-entries. Date bounds from the view are applied by
+// the caller.
+func parseEntryFilter(r *http.Request) (db.EntryFilter, error) {
+	query := r.URL.Query()
+	filter := db.EntryFilter{
+		Customer: strings.TrimSpace(query.Get("customer")),
+		Tail:     strings.TrimSpace(query.Get("tail")),
+		Search:   strings.TrimSpace(query.Get("q")),
+		SortBy:   query.Get("sort"),
+	}
+
+	for _, date := range []string{filter.BeginDate, filter.EndDate} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return db.EntryFilter{}, fmt.Errorf("Invalid date '%s', expected YYYY-MM-DD", date)
 		}
+	}
 
-		view := r.URL.Query().Get("view")
-		if view == "" {
-			view = "period"
+	if types := query.Get("type"); types != "" {
+		for _, entryType := range strings.Split(types, ",") {
+			if entryType = strings.TrimSpace(entryType); entryType != "" {
+				filter.Types = append(filter.Types, entryType)
+			}
 		}
+	}
 
-		date := r.URL.Query().Get("date")
-		if date == "" {
-			date = time.Now().In(time.Local).Format("2006-01-02")
+	if meeting := query.Get("meeting"); meeting != "" {
+		value, err := strconv.ParseBool(meeting)
+		if err != nil {
+			return db.EntryFilter{}, fmt.Errorf("Invalid meeting filter '%s'", meeting)
 		}
+		filter.Meeting = &value
+	}
 
-		var entries []db.Entry
-		var err error
-		var totals map[string]interface{}
+	switch strings.ToLower(query.Get("order")) {
+	case "", "asc":
+	case "desc":
+		filter.SortDesc = true
+	default:
+		return db.EntryFilter{}, fmt.Errorf("Invalid order '%s'. Use: asc or desc", query.Get("order"))
+	}
 
-		switch view {
-		case "day":
-			entries, err = database.FetchEntries(date, date)
+	for name, target := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		raw := query.Get(name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 0 {
+			return db.EntryFilter{}, fmt.Errorf("Invalid %s '%s'", name, raw)
+		}
+		*target = value
+	}
 
-		case "week":
-			startOfWeek, endOfWeek := getCurrentWeek(date)
-			entries, err = database.FetchEntries(startOfWeek, endOfWeek)
+	return filter, nil
+}
 
-		case "all":
-			entries, err = database.FetchEntries("all", "all")
+func setupGetTotals(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
 
-		default:
+		view, beginDate, endDate, err := requestRange(database, r)
+		if err != nil {
 			toJSON(w, db.Response{
 				Status:  "ERROR",
-				Message: "Invalid view type for totals",
+				Message: err.Error(),
 			})
 			return
 		}
 
+		totals, err := database.CalculateRangeTotals(beginDate, endDate)
 		if err != nil {
 			toJSON(w, db.Response{
 				Status:  "ERROR",
-				Message: fmt.Sprintf("Failed to fetch entries: %v", err),
+				Message: fmt.Sprintf("Failed to calculate totals: %v", err),
 			})
 			return
 		}
-
-		totals = calculateTotalsFromEntries(entries)
+		totals["view"] = view
+		totals["all_hours"] = totals["total_hours"]
+		totals["gross"] = totals["total_gross"]
 
 		data, _ := json.Marshal(totals)
 		toJSON(w, db.Response{
@@ -326,58 +437,3 @@ func setupGetTotals(database *db.Database) http.HandlerFunc {
 		})
 	}
 }
-
-func safeFloat64(ptr *float64) float64 {
-	if ptr != nil {
-		return *ptr
-	}
-	return 0.0
-}
-
-func calculateTotalsFromEntries(entries []db.Entry) map[string]interface{} {
-	var flightHours, groundHours, simHours, adminHours, totalHours float64
-	var totalGross float64
-	cfiRate := 26.50
-	adminRate := 13.75
-
-	for _, entry := range entries {
-		fh := safeFloat64(entry.FlightHours)
-		gh := safeFloat64(entry.GroundHours)
-		sh := safeFloat64(entry.SimHours)
-		ah := safeFloat64(entry.AdminHours)
-
-		if entry.Type == "admin" && entry.RideCount != nil && *entry.RideCount > 0 {
-			rideAdminHours := float64(*entry.RideCount) * 0.2
-			ah = rideAdminHours
-		}
-
-		flightHours += fh
-		groundHours += gh
-		simHours += sh
-		adminHours += ah
-		cfiHours := fh + gh + sh
-		cfiPay := cfiHours * cfiRate
-		adminPay := ah * adminRate
-		totalGross += cfiPay + adminPay
-	}
-
-	totalHours = flightHours + groundHours + simHours + adminHours
-
-	return map[string]interface{}{
-		"flight_hours": flightHours,
-		"ground_hours": groundHours,
-		"sim_hours":    simHours,
-		"admin_hours":  adminHours,
-		"all_hours":    totalHours,
-		"gross":        totalGross,
-		"cfi_rate":     cfiRate,
-		"admin_rate":   adminRate,
-	}
-}
-
-func getCurrentWeek(dateStr string) (string, string) {
-	date, _ := time.Parse("2006-01-02", dateStr)
-	startOfWeek := date.AddDate(0, 0, -int(date.Weekday())+1) // monday
-	endOfWeek := date                                         // today (not sunday)
-	return startOfWeek.Format("2006-01-02"), endOfWeek.Format("2006-01-02")
-}
@@ -0,0 +1,278 @@
+// Package ics reads and writes the small subset of iCalendar (RFC 5545)
+// the pay log exchanges with calendar apps: VEVENTs with a start, an end or
+// duration, a summary, a description and a status.
+package ics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405"
+)
+
+// Event is one calendar event. All-day events only use the date of Start
+// and End, where End is exclusive as in iCalendar.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Status      string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+}
+
+// Hours is the event's length, or zero for all-day events.
+func (event Event) Hours() float64 {
+	if event.AllDay || event.End.IsZero() {
+		return 0
+	}
+	return event.End.Sub(event.Start).Hours()
+}
+
+// Write renders events as a VCALENDAR. Timed events are written in floating
+// local time, matching how entries are logged.
+func Write(w io.Writer, name string, events []Event) error {
+	out := &writer{w: w}
+	out.line("BEGIN:VCALENDAR")
+	out.line("VERSION:2.0")
+	out.line("PRODID:-//pay-log//EN")
+	out.line("CALSCALE:GREGORIAN")
+	out.line("X-WR-CALNAME:" + escape(name))
+
+	stamp := time.Now().UTC().Format(dateTimeLayout) + "Z"
+	for _, event := range events {
+		out.line("BEGIN:VEVENT")
+		out.line("UID:" + event.UID)
+		out.line("DTSTAMP:" + stamp)
+		if event.AllDay {
+			end := event.End
+			if !end.After(event.Start) {
+				end = event.Start.AddDate(0, 0, 1)
+			}
+			out.line("DTSTART;VALUE=DATE:" + event.Start.Format(dateLayout))
+			out.line("DTEND;VALUE=DATE:" + end.Format(dateLayout))
+		} else {
+			out.line("DTSTART:" + event.Start.Format(dateTimeLayout))
+			out.line("DTEND:" + event.End.Format(dateTimeLayout))
+		}
+		out.line("SUMMARY:" + escape(event.Summary))
+		if event.Description != "" {
+			out.line("DESCRIPTION:" + escape(event.Description))
+		}
+		if event.Status != "" {
+			out.line("STATUS:" + event.Status)
+		}
+		out.line("END:VEVENT")
+	}
+	out.line("END:VCALENDAR")
+	return out.err
+}
+
+type writer struct {
+	w   io.Writer
+	err error
+}
+
+// line writes one content line, folded at 75 octets with CRLF endings.
+func (out *writer) line(text string) {
+	if out.err != nil {
+		return
+	}
+	for len(text) > 75 {
+		cut := 75
+		for cut > 0 && !isRuneStart(text[cut]) {
+			cut--
+		}
+		_, out.err = io.WriteString(out.w, text[:cut]+"\r\n")
+		text = " " + text[cut:]
+	}
+	_, out.err = io.WriteString(out.w, text+"\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+var textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+func escape(text string) string {
+	return textEscaper.Replace(text)
+}
+
+// Parse reads every VEVENT from an iCalendar stream. Times with a TZID are
+// converted to local time when the zone is known; UTC times likewise.
+func Parse(r io.Reader) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	var event *Event
+	var duration time.Duration
+	for number, raw := range lines {
+		name, params, value := splitLine(raw)
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			event = &Event{}
+			duration = 0
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			if event == nil {
+				return nil, fmt.Errorf("line %d: END:VEVENT without BEGIN", number+1)
+			}
+			if event.Start.IsZero() {
+				return nil, fmt.Errorf("line %d: event %q has no DTSTART", number+1, event.Summary)
+			}
+			if event.End.IsZero() {
+				switch {
+				case duration > 0:
+					event.End = event.Start.Add(duration)
+				case event.AllDay:
+					event.End = event.Start.AddDate(0, 0, 1)
+				default:
+					event.End = event.Start
+				}
+			}
+			events = append(events, *event)
+			event = nil
+		case event == nil:
+			continue
+		case name == "UID":
+			event.UID = value
+		case name == "SUMMARY":
+			event.Summary = textUnescaper.Replace(value)
+		case name == "DESCRIPTION":
+			event.Description = textUnescaper.Replace(value)
+		case name == "STATUS":
+			event.Status = strings.ToUpper(value)
+		case name == "DTSTART", name == "DTEND":
+			when, allDay, err := parseTime(value, params)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", number+1, err)
+			}
+			if name == "DTSTART" {
+				event.Start, event.AllDay = when, allDay
+			} else {
+				event.End = when
+			}
+		case name == "DURATION":
+			duration, err = parseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", number+1, err)
+			}
+		}
+	}
+	return events, nil
+}
+
+// unfold joins continuation lines (those starting with a space or tab).
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t")) {
+			lines[len(lines)-1] += text[1:]
+			continue
+		}
+		if text != "" {
+			lines = append(lines, text)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read calendar: %w", err)
+	}
+	return lines, nil
+}
+
+// splitLine separates "NAME;PARAM=x:value" into its parts. Parameter names
+// are upper-cased; quoted parameter values may contain ':' and ';'.
+func splitLine(line string) (string, map[string]string, string) {
+	params := map[string]string{}
+	inQuotes := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		} else if r == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return strings.ToUpper(line), params, ""
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	for _, param := range parts[1:] {
+		if key, value, ok := strings.Cut(param, "="); ok {
+			params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, line[colon+1:]
+}
+
+func parseTime(value string, params map[string]string) (time.Time, bool, error) {
+	if params["VALUE"] == "DATE" || len(value) == len(dateLayout) {
+		date, err := time.ParseInLocation(dateLayout, value, time.Local)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid date %q", value)
+		}
+		return date, true, nil
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		when, err := time.Parse(dateTimeLayout, strings.TrimSuffix(value, "Z"))
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid time %q", value)
+		}
+		return when.In(time.Local), false, nil
+	}
+
+	location := time.Local
+	if zone := params["TZID"]; zone != "" {
+		if loaded, err := time.LoadLocation(zone); err == nil {
+			location = loaded
+		}
+	}
+	when, err := time.ParseInLocation(dateTimeLayout, value, location)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid time %q", value)
+	}
+	return when.In(time.Local), false, nil
+}
+
+var durationPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseDuration reads an iCalendar DURATION such as PT1H30M or P1D.
+func parseDuration(value string) (time.Duration, error) {
+	match := durationPattern.FindStringSubmatch(value)
+	if match == nil || value == "P" || value == "PT" {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var duration time.Duration
+	for i, unit := range units {
+		if match[i+2] == "" {
+			continue
+		}
+		n, _ := strconv.Atoi(match[i+2])
+		duration += time.Duration(n) * unit
+	}
+	if match[1] == "-" {
+		duration = -duration
+	}
+	return duration, nil
+}
@@ -0,0 +1,153 @@
+package ics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sample = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:timed-1@example.com\r\n" +
+	"DTSTART:20250310T150000\r\n" +
+	"DTEND:20250310T163000\r\n" +
+	"SUMMARY:Flight w/ Alice\\, checkride prep\r\n" +
+	"DESCRIPTION:Bring the\\nlogbook\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:duration-2@example.com\r\n" +
+	"DTSTART;TZID=\"America/New_York\":20250311T090000\r\n" +
+	"DURATION:PT1H15M\r\n" +
+	"SUMMARY:Ground lesson with a very long summary that a calendar app has\r\n" +
+	"  folded onto a second line\r\n" +
+	"STATUS:cancelled\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:allday-3@example.com\r\n" +
+	"DTSTART;VALUE=DATE:20250312\r\n" +
+	"SUMMARY:Staff meeting\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParse(t *testing.T) {
+	events, err := Parse(strings.NewReader(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3", len(events))
+	}
+
+	timed := events[0]
+	if timed.UID != "timed-1@example.com" || timed.Summary != "Flight w/ Alice, checkride prep" {
+		t.Errorf("timed event = %+v", timed)
+	}
+	if timed.Description != "Bring the\nlogbook" {
+		t.Errorf("description = %q, want escapes undone", timed.Description)
+	}
+	if timed.AllDay || timed.Hours() != 1.5 {
+		t.Errorf("timed event hours = %v (all day %v), want 1.5", timed.Hours(), timed.AllDay)
+	}
+	if got := timed.Start.Format("2006-01-02 15:04"); got != "2025-03-10 15:00" {
+		t.Errorf("floating start = %s, want local 2025-03-10 15:00", got)
+	}
+
+	zoned := events[1]
+	if zoned.Hours() != 1.25 {
+		t.Errorf("DURATION event hours = %v, want 1.25", zoned.Hours())
+	}
+	if zoned.Status != "CANCELLED" {
+		t.Errorf("status = %q, want CANCELLED", zoned.Status)
+	}
+	if !strings.HasSuffix(zoned.Summary, "has folded onto a second line") {
+		t.Errorf("folded summary = %q", zoned.Summary)
+	}
+	if newYork, err := time.LoadLocation("America/New_York"); err == nil {
+		want := time.Date(2025, 3, 11, 9, 0, 0, 0, newYork)
+		if !zoned.Start.Equal(want) {
+			t.Errorf("TZID start = %v, want %v", zoned.Start, want)
+		}
+	}
+
+	allDay := events[2]
+	if !allDay.AllDay || allDay.Hours() != 0 {
+		t.Errorf("all-day event = %+v, want all day with no hours", allDay)
+	}
+	if got := allDay.End.Sub(allDay.Start); got != 24*time.Hour {
+		t.Errorf("all-day event spans %v, want one day", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"no start":     "BEGIN:VEVENT\r\nSUMMARY:x\r\nEND:VEVENT\r\n",
+		"bad time":     "BEGIN:VEVENT\r\nDTSTART:20251340T250000\r\nEND:VEVENT\r\n",
+		"bad duration": "BEGIN:VEVENT\r\nDTSTART:20250310T150000\r\nDURATION:1 hour\r\nEND:VEVENT\r\n",
+		"stray end":    "END:VEVENT\r\n",
+	}
+	for name, text := range tests {
+		if _, err := Parse(strings.NewReader(text)); err == nil {
+			t.Errorf("%s: want an error", name)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"PT1H30M": 90 * time.Minute,
+		"PT45M":   45 * time.Minute,
+		"P1D":     24 * time.Hour,
+		"P1W":     7 * 24 * time.Hour,
+		"-PT15M":  -15 * time.Minute,
+	}
+	for value, want := range tests {
+		got, err := parseDuration(value)
+		if err != nil || got != want {
+			t.Errorf("parseDuration(%q) = %v, %v; want %v", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"P", "PT", "1H", "PT1X"} {
+		if _, err := parseDuration(value); err == nil {
+			t.Errorf("parseDuration(%q) want an error", value)
+		}
+	}
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	start := time.Date(2025, 3, 10, 15, 0, 0, 0, time.Local)
+	written := []Event{
+		{UID: "entry-1@pay-log", Summary: "Flight 1.50h - Smith, J.", Description: "line one\nline two",
+			Status: "CONFIRMED", Start: start, End: start.Add(90 * time.Minute)},
+		{UID: "lesson-2@pay-log", Summary: "Scheduled ground 1.00h", Status: "CONFIRMED",
+			Start: time.Date(2025, 3, 12, 0, 0, 0, 0, time.Local), AllDay: true},
+	}
+
+	var out bytes.Buffer
+	if err := Write(&out, "Pay Log", written); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(out.String(), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+	}
+
+	events, err := Parse(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("read back %d events, want 2", len(events))
+	}
+	if events[0].Summary != written[0].Summary || events[0].Description != written[0].Description {
+		t.Errorf("text changed in round trip: %+v", events[0])
+	}
+	if !events[0].Start.Equal(start) || events[0].Hours() != 1.5 {
+		t.Errorf("timed event read back as %v for %vh", events[0].Start, events[0].Hours())
+	}
+	if !events[1].AllDay {
+		t.Errorf("all-day event read back as timed")
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	db "github.com/theHousedev/pay-log/backend/database"
+)
+
+// lessonRange resolves the request's view like requestRange, with the
+// "all" view spanning the first to the last scheduled lesson.
+func lessonRange(database *db.Database, r *http.Request) (string, string, error) {
+	_, beginDate, endDate, err := requestRange(database, r)
+	if err != nil || (beginDate != "all" && endDate != "all") {
+		return beginDate, endDate, err
+	}
+	firstDate, lastDate, err := database.LessonDateRange()
+	if err != nil {
+		return "", "", err
+	}
+	return keepIfOpen(beginDate, firstDate), keepIfOpen(endDate, lastDate), nil
+}
+
+// setupLessons lists scheduled lessons in a view (GET, optional ?status=),
+// schedules one (POST) or edits an open one (PUT).
+func setupLessons(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			beginDate, endDate, err := lessonRange(database, r)
+			if err != nil {
+				toJSON(w, db.Response{
+					Status:  "ERROR",
+					Message: err.Error(),
+				})
+				return
+			}
+
+			lessons, err := database.GetLessons(beginDate, endDate, r.URL.Query().Get("status"))
+			if err != nil {
+				toJSON(w, db.Response{
+					Status:  "ERROR",
+					Message: fmt.Sprintf("Failed to get lessons: %v", err),
+				})
+				return
+			}
+
+			data, _ := json.Marshal(lessons)
+			toJSON(w, db.Response{
+				Status:  "OK",
+				Message: fmt.Sprintf("Lessons retrieved from %s to %s", beginDate, endDate),
+				Data:    data,
+			})
+
+		case http.MethodPost, http.MethodPut:
+			var lesson db.ScheduledLesson
+			if err := json.NewDecoder(r.Body).Decode(&lesson); err != nil {
+				toJSON(w, db.Response{
+					Status:  "ERROR",
+					Message: "Invalid JSON format",
+				})
+				return
+			}
+
+			if r.Method == http.MethodPost {
+				toJSON(w, database.CreateLesson(lesson))
+			} else {
+				toJSON(w, database.UpdateLesson(lesson))
+			}
+
+		default:
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// setupLessonStatus cancels a lesson, records a no-show, or reopens it.
+func setupLessonStatus(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var request struct {
+			ID     int    `json:"id"`
+			Status string `json:"status"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: "Invalid JSON format",
+			})
+			return
+		}
+
+		toJSON(w, database.SetLessonStatus(request.ID, request.Status))
+	}
+}
+
+// setupCompleteLesson logs a lesson as a pay entry. Any entry fields sent
+// override what the lesson planned.
+func setupCompleteLesson(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var request struct {
+			LessonID int      `json:"lesson_id"`
+			Entry    db.Entry `json:"entry"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: "Invalid JSON format",
+			})
+			return
+		}
+
+		toJSON(w, database.CompleteLesson(request.LessonID, request.Entry))
+	}
+}
+
+// setupLessonSummary reports scheduled, completed, cancelled and no-show
+// lessons per pay period in a view, with the income lost to cancellations.
+func setupLessonSummary(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		beginDate, endDate, err := lessonRange(database, r)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: err.Error(),
+			})
+			return
+		}
+
+		periods, err := database.GetLessonSummaries(beginDate, endDate)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to summarize lessons: %v", err),
+			})
+			return
+		}
+
+		total := db.LessonSummary{BeginDate: beginDate, EndDate: endDate}
+		for _, period := range periods {
+			total.Scheduled += period.Scheduled
+			total.Completed += period.Completed
+			total.Cancelled += period.Cancelled
+			total.NoShows += period.NoShows
+			total.PlannedHours += period.PlannedHours
+			total.CompletedHours += period.CompletedHours
+			total.ActualHours += period.ActualHours
+			total.LostHours += period.LostHours
+			total.LostIncome += period.LostIncome
+		}
+
+		data, _ := json.Marshal(map[string]interface{}{
+			"periods": periods,
+			"total":   total,
+		})
+		toJSON(w, db.Response{
+			Status:  "OK",
+			Message: fmt.Sprintf("Lesson summary from %s to %s", beginDate, endDate),
+			Data:    data,
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	db "github.com/theHousedev/pay-log/backend/database"
+)
+
+type logbookLayout struct {
+	write func(writer *csv.Writer, entries []db.Entry, fleet map[string]db.Aircraft)
+}
+
+// logbookLayouts are the CSV files the export can produce. "standard" is a
+// plain logbook; "foreflight" is ForeFlight's logbook import template.
+var logbookLayouts = map[string]logbookLayout{
+	"standard":   {write: writeStandardLogbook},
+	"foreflight": {write: writeForeFlightLogbook},
+}
+
+func writeStandardLogbook(writer *csv.Writer, entries []db.Entry, _ map[string]db.Aircraft) {
+	writer.Write([]string{"Date", "Aircraft ID", "Aircraft Type", "Route", "Total Time", "Dual Given",
+		"Night", "Actual Instrument", "Cross Country", "Day Landings", "Night Landings", "Remarks"})
+	for _, entry := range entries {
+		writer.Write([]string{
+			entryDay(entry), deref(entry.TailNumber), deref(entry.AircraftType), logbookRoute(entry),
+			logHours(entry.FlightHours), logHours(entry.FlightHours),
+			logHours(entry.NightHours), logHours(entry.IFRHours), logHours(entry.XCHours),
+			logCount(entry.DayLandings), logCount(entry.NightLandings), logbookRemarks(entry),
+		})
+	}
+}
+
+// foreFlightColumn is one column of a ForeFlight import table: its header
+// and the data type named in the row above the header.
+type foreFlightColumn struct {
+	name, kind string
+}
+
+var foreFlightAircraftColumns = []foreFlightColumn{
+	{"AircraftID", "Text"}, {"equipType (FAA)", "Text"}, {"TypeCode", "Text"}, {"Year", "YYYY"},
+	{"Make", "Text"}, {"Model", "Text"}, {"Category", "Text"}, {"Class", "Text"},
+	{"GearType", "Text"}, {"EngineType", "Text"}, {"Complex", "Boolean"},
+	{"HighPerformance", "Boolean"}, {"Pressurized", "Boolean"}, {"TAA", "Boolean"},
+}
+
+var foreFlightFlightColumns = []foreFlightColumn{
+	{"Date", "Date"}, {"AircraftID", "Text"}, {"From", "Text"}, {"To", "Text"}, {"Route", "Text"},
+	{"TimeOut", "hhmm"}, {"TimeOff", "hhmm"}, {"TimeOn", "hhmm"}, {"TimeIn", "hhmm"},
+	{"OnDuty", "hhmm"}, {"OffDuty", "hhmm"},
+	{"TotalTime", "Decimal"}, {"PIC", "Decimal"}, {"SIC", "Decimal"}, {"Night", "Decimal"},
+	{"Solo", "Decimal"}, {"CrossCountry", "Decimal"}, {"NVG", "Decimal"}, {"NVGOps", "Number"},
+	{"Distance", "Decimal"}, {"DayTakeoffs", "Number"}, {"DayLandingsFullStop", "Number"},
+	{"NightTakeoffs", "Number"}, {"NightLandingsFullStop", "Number"}, {"AllLandings", "Number"},
+	{"ActualInstrument", "Decimal"}, {"SimulatedInstrument", "Decimal"},
+	{"HobbsStart", "Decimal"}, {"HobbsEnd", "Decimal"}, {"TachStart", "Decimal"}, {"TachEnd", "Decimal"},
+	{"Holds", "Number"},
+	{"Approach1", "Packed Detail"}, {"Approach2", "Packed Detail"}, {"Approach3", "Packed Detail"},
+	{"Approach4", "Packed Detail"}, {"Approach5", "Packed Detail"}, {"Approach6", "Packed Detail"},
+	{"DualGiven", "Decimal"}, {"DualReceived", "Decimal"}, {"SimulatedFlight", "Decimal"},
+	{"GroundTraining", "Decimal"}, {"InstructorName", "Text"}, {"InstructorComments", "Text"},
+	{"Person1", "Packed Detail"}, {"Person2", "Packed Detail"}, {"Person3", "Packed Detail"},
+	{"Person4", "Packed Detail"}, {"Person5", "Packed Detail"}, {"Person6", "Packed Detail"},
+	{"FlightReview", "Boolean"}, {"Checkride", "Boolean"}, {"IPC", "Boolean"},
+	{"NVGProficiency", "Boolean"}, {"FAA6158", "Boolean"}, {"PilotComments", "Text"},
+}
+
+// writeForeFlightTable writes a titled table: the title, the type row, the
+// header row, then each row's values placed by column name.
+func writeForeFlightTable(writer *csv.Writer, title string, columns []foreFlightColumn, rows []map[string]string) {
+	writer.Write([]string{title})
+	kinds := make([]string, len(columns))
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		kinds[i] = column.kind
+		names[i] = column.name
+	}
+	writer.Write(kinds)
+	writer.Write(names)
+	for _, values := range rows {
+		record := make([]string, len(columns))
+		for i, column := range columns {
+			record[i] = values[column.name]
+		}
+		writer.Write(record)
+	}
+}
+
+// writeForeFlightLogbook writes ForeFlight's import file: the required
+// first line, an Aircraft Table listing each tail flown (with its make and
+// model from the aircraft registry when known), and the Flights Table.
+func writeForeFlightLogbook(writer *csv.Writer, entries []db.Entry, fleet map[string]db.Aircraft) {
+	writer.Write([]string{"ForeFlight Logbook Import", "This row is required for importing into ForeFlight. Do not delete or modify."})
+	writer.Write([]string{""})
+
+	var aircraftRows []map[string]string
+	seen := map[string]bool{}
+	for _, entry := range entries {
+		tail := deref(entry.TailNumber)
+		if tail == "" || seen[tail] {
+			continue
+		}
+		seen[tail] = true
+		aircraftRows = append(aircraftRows, map[string]string{
+			"AircraftID": tail,
+			"TypeCode":   deref(entry.AircraftType),
+			"Model":      fleet[tail].MakeModel,
+		})
+	}
+	writeForeFlightTable(writer, "Aircraft Table", foreFlightAircraftColumns, aircraftRows)
+	writer.Write([]string{""})
+
+	var flightRows []map[string]string
+	for _, entry := range entries {
+		landings := 0
+		for _, count := range []*int{entry.DayLandings, entry.NightLandings} {
+			if count != nil {
+				landings += *count
+			}
+		}
+		flightRows = append(flightRows, map[string]string{
+			"Date":                  entryDay(entry),
+			"AircraftID":            deref(entry.TailNumber),
+			"From":                  deref(entry.Departure),
+			"To":                    deref(entry.Arrival),
+			"TotalTime":             logHours(entry.FlightHours),
+			"Night":                 logHours(entry.NightHours),
+			"CrossCountry":          logHours(entry.XCHours),
+			"NightLandingsFullStop": logCount(entry.NightLandings),
+			"AllLandings":           logCount(&landings),
+			"ActualInstrument":      logHours(entry.IFRHours),
+			"HobbsStart":            logHours(entry.HobbsStart),
+			"HobbsEnd":              logHours(entry.HobbsEnd),
+			"TachStart":             logHours(entry.TachStart),
+			"TachEnd":               logHours(entry.TachEnd),
+			"DualGiven":             logHours(entry.FlightHours),
+			"PilotComments":         logbookRemarks(entry),
+		})
+	}
+	writeForeFlightTable(writer, "Flights Table", foreFlightFlightColumns, flightRows)
+}
+
+func entryDay(entry db.Entry) string {
+	return strings.Split(entry.Date, "T")[0]
+}
+
+func deref(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// logHours writes logbook time in tenths, blank when not recorded.
+func logHours(hours *float64) string {
+	if hours == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*hours, 'f', 1, 64)
+}
+
+func logCount(count *int) string {
+	if count == nil || *count == 0 {
+		return ""
+	}
+	return strconv.Itoa(*count)
+}
+
+func logbookRoute(entry db.Entry) string {
+	var stops []string
+	for _, airport := range []*string{entry.Departure, entry.Arrival} {
+		if airport != nil {
+			stops = append(stops, *airport)
+		}
+	}
+	return strings.Join(stops, "-")
+}
+
+func logbookRemarks(entry db.Entry) string {
+	var parts []string
+	if entry.Customer != nil && *entry.Customer != "" {
+		parts = append(parts, "Dual given to "+*entry.Customer)
+	}
+	if entry.Notes != nil && *entry.Notes != "" {
+		parts = append(parts, *entry.Notes)
+	}
+	return strings.Join(parts, ". ")
+}
+
+// setupLogbookExport downloads flight entries as a logbook CSV. It takes
+// the same view and filter parameters as /api/get-entries (non-flight
+// types are never exported) plus ?layout=standard|foreflight. Rows are
+// oldest first unless another sort is asked for.
+func setupLogbookExport(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		layoutName := r.URL.Query().Get("layout")
+		if layoutName == "" {
+			layoutName = "standard"
+		}
+		layout, ok := logbookLayouts[layoutName]
+		if !ok {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Invalid layout '%s'. Use: standard or foreflight", layoutName),
+			})
+			return
+		}
+
+		_, filter, err := viewEntryFilter(database, r)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: err.Error(),
+			})
+			return
+		}
+		filter.Types = []string{"flight"}
+		if filter.SortBy == "" {
+			filter.SortBy = "date"
+		}
+
+		entries, _, err := database.FetchFilteredEntries(filter)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to get entries: %v", err),
+			})
+			return
+		}
+
+		fleet := map[string]db.Aircraft{}
+		aircraft, err := database.GetAircraft(nil)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to get aircraft: %v", err),
+			})
+			return
+		}
+		for _, plane := range aircraft {
+			fleet[plane.TailNumber] = plane
+		}
+
+		beginDate, endDate, err := logbookSpan(database, filter, entries)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to get entry range: %v", err),
+			})
+			return
+		}
+
+		attachment(w, "text/csv", fmt.Sprintf("logbook-%s-%s.csv", beginDate, endDate))
+		writer := csv.NewWriter(w)
+		layout.write(writer, entries, fleet)
+		writer.Flush()
+	}
+}
+
+// logbookSpan names the export's dates, replacing an open ("all" or empty)
+// bound with the first or last exported flight (or logged entry, when none
+// matched).
+func logbookSpan(database *db.Database, filter db.EntryFilter, entries []db.Entry) (string, string, error) {
+	open := func(date string) bool { return date == "" || date == "all" }
+	beginDate, endDate := filter.BeginDate, filter.EndDate
+	if !open(beginDate) && !open(endDate) {
+		return beginDate, endDate, nil
+	}
+
+	var first, last string
+	for _, entry := range entries {
+		day := entryDay(entry)
+		if first == "" || day < first {
+			first = day
+		}
+		if day > last {
+			last = day
+		}
+	}
+	if first == "" {
+		var err error
+		if first, last, err = database.EntryDateRange(); err != nil {
+			return "", "", err
+		}
+	}
+	if open(beginDate) {
+		beginDate = first
+	}
+	if open(endDate) {
+		endDate = last
+	}
+	return beginDate, endDate, nil
+}
@@ -1,14 +1,19 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
 
 	"github.com/joho/godotenv"
 	"github.com/rs/cors"
 	db "github.com/theHousedev/pay-log/backend/database"
+	"github.com/theHousedev/pay-log/backend/deductions"
+	"github.com/theHousedev/pay-log/backend/paystub"
+	"github.com/theHousedev/pay-log/backend/timesheet"
 	"go.yaml.in/yaml/v3"
 )
 
@@ -18,8 +23,30 @@ type Ports struct {
 	Production string `yaml:"production"`
 }
 
+type Week struct {
+	Start  string `yaml:"start"`
+	ToDate bool   `yaml:"to_date"`
+}
+
+type Deductions struct {
+	File string `yaml:"file"`
+}
+
+type Paystub struct {
+	File string `yaml:"file"`
+}
+
+type Timesheet struct {
+	File string `yaml:"file"`
+}
+
 type SiteConfig struct {
-	Ports Ports `yaml:"ports"`
+	Ports      Ports       `yaml:"ports"`
+	Week       Week        `yaml:"week"`
+	Deductions Deductions  `yaml:"deductions"`
+	Paystub    Paystub     `yaml:"paystub"`
+	Timesheet  Timesheet   `yaml:"timesheet"`
+	Rounding   db.Rounding `yaml:"rounding"`
 }
 
 func loadConfig() (*SiteConfig, error) {
@@ -32,6 +59,15 @@ func loadConfig() (*SiteConfig, error) {
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
+	if cfg.Deductions.File != "" && !filepath.IsAbs(cfg.Deductions.File) {
+		cfg.Deductions.File = filepath.Join(filepath.Dir(cfgPath), cfg.Deductions.File)
+	}
+	if cfg.Paystub.File != "" && !filepath.IsAbs(cfg.Paystub.File) {
+		cfg.Paystub.File = filepath.Join(filepath.Dir(cfgPath), cfg.Paystub.File)
+	}
+	if cfg.Timesheet.File != "" && !filepath.IsAbs(cfg.Timesheet.File) {
+		cfg.Timesheet.File = filepath.Join(filepath.Dir(cfgPath), cfg.Timesheet.File)
+	}
 	return &cfg, nil
 }
 
@@ -58,18 +94,66 @@ func openDB(dbPath string) *db.Database {
 }
 
 func main() {
+	rebuildStats := flag.Bool("rebuild-stats", false, "recalculate monthly_stats from pay_entries and exit")
+	flag.Parse()
+
 	err := godotenv.Load()
 	if err != nil {
 		log.Fatal("Error loading .env")
 	}
 
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Fatal("failed to load config: ", err)
+	}
+
+	if cfg.Week.Start != "" {
+		weekSettings.Start, err = parseWeekday(cfg.Week.Start)
+		if err != nil {
+			log.Fatal("failed to load config: ", err)
+		}
+	}
+	weekSettings.ToDate = cfg.Week.ToDate
+
+	if cfg.Deductions.File != "" {
+		deductionModel, err = deductions.Load(cfg.Deductions.File)
+		if err != nil {
+			log.Fatal("failed to load deductions: ", err)
+		}
+	}
+
+	if cfg.Paystub.File != "" {
+		stubPatterns, err = paystub.Load(cfg.Paystub.File)
+		if err != nil {
+			log.Fatal("failed to load pay stub patterns: ", err)
+		}
+	}
+
+	if err := cfg.Rounding.Validate(); err != nil {
+		log.Fatal("failed to load config: ", err)
+	}
+
+	if cfg.Timesheet.File != "" {
+		timesheetTemplate, err = timesheet.Load(cfg.Timesheet.File)
+		if err != nil {
+			log.Fatal("failed to load timesheet template: ", err)
+		}
+	}
+
+	// the database is opened only once the config is known to be good, so
+	// pay and stats (including --rebuild-stats) use its rounding
 	dbPath := "./pay_log.db"
 	database := openDB(dbPath)
 	defer database.Close()
+	database.Rounding = cfg.Rounding
 
-	cfg, err := loadConfig()
-	if err != nil {
-		log.Fatal("failed to load config: ", err)
+	if *rebuildStats {
+		months, err := database.RebuildMonthlyStats()
+		if err != nil {
+			log.Fatal("monthly stats rebuild failed: ", err)
+		}
+		fmt.Printf("\x1b[32m"+"rebuilt monthly stats for %d months"+"\x1b[0m\n", months)
+		return
 	}
 
 	env := os.Getenv("ENVIRONMENT")
@@ -88,8 +172,41 @@ func main() {
 	http.HandleFunc("/api/health", setupCheckHealth(database))
 	http.HandleFunc("/api/current-period", auth(setupCurrentPeriod(database)))
 	http.HandleFunc("/api/periods", auth(setupGetAllPeriods(database)))
+	http.HandleFunc("/api/periods/net", auth(setupPeriodNet(database)))
+	http.HandleFunc("/api/periods/{id}/report.pdf", auth(setupPeriodReport(database)))
 	http.HandleFunc("/api/get-entries", auth(setupGetEntries(database)))
 	http.HandleFunc("/api/get-totals", auth(setupGetTotals(database)))
+	http.HandleFunc("/api/stats/monthly", auth(setupMonthlyStats(database)))
+	http.HandleFunc("/api/stats/summary", auth(setupYearSummary(database)))
+	http.HandleFunc("/api/stats/series", auth(setupTimeSeries(database)))
+	http.HandleFunc("/api/projection", auth(setupProjection(database)))
+	http.HandleFunc("/api/goals", auth(setupGoals(database)))
+	http.HandleFunc("/api/rates", auth(setupRates(database)))
+	http.HandleFunc("/api/premiums", auth(setupPremiums(database)))
+	http.HandleFunc("/api/customers", auth(setupCustomers(database)))
+	http.HandleFunc("/api/customers/alias", auth(setupAddCustomerAlias(database)))
+	http.HandleFunc("/api/customers/merge", auth(setupMergeCustomers(database)))
+	http.HandleFunc("/api/customers/history", auth(setupCustomerHistory(database)))
+	http.HandleFunc("/api/customers/totals", auth(setupCustomerTotals(database)))
+	http.HandleFunc("/api/customers/progress", auth(setupCustomerProgress(database)))
+	http.HandleFunc("/api/lessons", auth(setupLessons(database)))
+	http.HandleFunc("/api/lessons/status", auth(setupLessonStatus(database)))
+	http.HandleFunc("/api/lessons/complete", auth(setupCompleteLesson(database)))
+	http.HandleFunc("/api/lessons/summary", auth(setupLessonSummary(database)))
+	http.HandleFunc("/api/calendar.ics", feedAuth(setupCalendarFeed(database)))
+	http.HandleFunc("/api/calendar/import", auth(setupCalendarImport(database)))
+	http.HandleFunc("/api/drafts", auth(setupDrafts(database)))
+	http.HandleFunc("/api/drafts/confirm", auth(setupConfirmDraft(database)))
+	http.HandleFunc("/api/drafts/discard", auth(setupDiscardDraft(database)))
+	http.HandleFunc("/api/aircraft", auth(setupAircraft(database)))
+	http.HandleFunc("/api/aircraft/hours", auth(setupAircraftHours(database)))
+	http.HandleFunc("/api/logbook/export", auth(setupLogbookExport(database)))
+	http.HandleFunc("/api/credentials", auth(setupCredentials(database)))
+	http.HandleFunc("/api/currency", auth(setupCurrency(database, false)))
+	http.HandleFunc("/api/currency/expiring", auth(setupCurrency(database, true)))
+	http.HandleFunc("/api/paystubs", auth(setupPayStubs(database)))
+	http.HandleFunc("/api/paystubs/upload", auth(setupPayStubUpload(database)))
+	http.HandleFunc("/api/timesheet", auth(setupTimesheet(database)))
 	fmt.Printf("\x1b[32m"+"running on 0.0.0.0:%s"+"\x1b[0m\n", port)
 	var handler http.Handler = http.DefaultServeMux
 	allowedOriginLoc := os.Getenv("ALLOWED_ORIGIN")
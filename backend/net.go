@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+
+	db "github.com/theHousedev/pay-log/backend/database"
+	"github.com/theHousedev/pay-log/backend/deductions"
+)
+
+// deductionModel is loaded from the file named in cfg.yaml. Without one,
+// net estimates are left out of responses.
+var deductionModel *deductions.Model
+
+func estimateNet(gross float64) *float64 {
+	if deductionModel == nil {
+		return nil
+	}
+	net := deductionModel.Estimate(gross).Net
+	return &net
+}
+
+type periodNet struct {
+	ID            int                 `json:"id"`
+	BeginDate     string              `json:"begin_date"`
+	EndDate       string              `json:"end_date"`
+	PayDate       string              `json:"pay_date"`
+	ExpectedGross float64             `json:"expected_gross"`
+	Estimate      deductions.Estimate `json:"estimate"`
+	ActualGross   *float64            `json:"actual_gross,omitempty"`
+	ActualNet     *float64            `json:"actual_net,omitempty"`
+	NetDifference *float64            `json:"net_difference,omitempty"`
+}
+
+// setupPeriodNet itemizes estimated deductions for every pay period and,
+// where actual net pay has been recorded, the difference from the estimate.
+func setupPeriodNet(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if deductionModel == nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: "No deduction model configured",
+			})
+			return
+		}
+
+		periods, err := database.GetAllPeriods()
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to get pay periods: %v", err),
+			})
+			return
+		}
+
+		results := make([]periodNet, 0, len(periods))
+		for _, period := range periods {
+			result := periodNet{
+				ID:            period.ID,
+				BeginDate:     period.BeginDate,
+				EndDate:       period.EndDate,
+				PayDate:       period.PayDate,
+				ExpectedGross: *period.GrossEarned,
+				Estimate:      deductionModel.Estimate(*period.GrossEarned),
+				ActualGross:   period.GrossActual,
+				ActualNet:     period.NetActual,
+			}
+			if period.NetActual != nil {
+				difference := math.Round((*period.NetActual-result.Estimate.Net)*100) / 100
+				result.NetDifference = &difference
+			}
+			results = append(results, result)
+		}
+
+		data, _ := json.Marshal(map[string]interface{}{
+			"model":   deductionModel,
+			"periods": results,
+		})
+		toJSON(w, db.Response{
+			Status:  "OK",
+			Message: "Net pay estimates retrieved",
+			Data:    data,
+		})
+	}
+}
@@ -0,0 +1,200 @@
+// Package paystub reads pay stubs exported as text or text-based PDF and
+// extracts the figures needed to reconcile a pay period, using regular
+// expressions configured per payroll provider.
+package paystub
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Patterns says where each figure is on a stub. Every field is a list of
+// regular expressions tried in order; the first capture group of the first
+// match is the value. Earnings patterns match once per earnings line and
+// use the named groups code, hours, rate (optional) and amount.
+type Patterns struct {
+	DateFormats []string `yaml:"date_formats"`
+	PayDate     []string `yaml:"pay_date"`
+	PeriodStart []string `yaml:"period_start"`
+	PeriodEnd   []string `yaml:"period_end"`
+	Gross       []string `yaml:"gross"`
+	Net         []string `yaml:"net"`
+	Earnings    []string `yaml:"earnings"`
+
+	compiled map[string][]*regexp.Regexp
+}
+
+type Earning struct {
+	Code   string   `json:"code"`
+	Hours  *float64 `json:"hours,omitempty"`
+	Rate   *float64 `json:"rate,omitempty"`
+	Amount float64  `json:"amount"`
+}
+
+// Stub is what was found on one pay stub. Dates are YYYY-MM-DD; anything
+// not found is left empty.
+type Stub struct {
+	PayDate     string    `json:"pay_date,omitempty"`
+	PeriodStart string    `json:"period_start,omitempty"`
+	PeriodEnd   string    `json:"period_end,omitempty"`
+	Gross       *float64  `json:"gross,omitempty"`
+	Net         *float64  `json:"net,omitempty"`
+	Earnings    []Earning `json:"earnings"`
+	TotalHours  float64   `json:"total_hours"`
+}
+
+var defaultDateFormats = []string{
+	"01/02/2006", "1/2/2006", "2006-01-02", "01/02/06", "1/2/06",
+	"Jan 2, 2006", "January 2, 2006", "02-Jan-2006",
+}
+
+// Load reads stub patterns from a YAML file and compiles them.
+func Load(path string) (*Patterns, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pay stub patterns: %w", err)
+	}
+
+	var patterns Patterns
+	if err := yaml.Unmarshal(data, &patterns); err != nil {
+		return nil, fmt.Errorf("failed to parse pay stub patterns: %w", err)
+	}
+	if len(patterns.DateFormats) == 0 {
+		patterns.DateFormats = defaultDateFormats
+	}
+
+	patterns.compiled = map[string][]*regexp.Regexp{}
+	fields := map[string][]string{
+		"pay_date":     patterns.PayDate,
+		"period_start": patterns.PeriodStart,
+		"period_end":   patterns.PeriodEnd,
+		"gross":        patterns.Gross,
+		"net":          patterns.Net,
+		"earnings":     patterns.Earnings,
+	}
+	for name, sources := range fields {
+		for _, source := range sources {
+			pattern, err := regexp.Compile(source)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s pattern %q: %w", name, source, err)
+			}
+			if name == "earnings" && (pattern.SubexpIndex("code") < 0 || pattern.SubexpIndex("amount") < 0) {
+				return nil, fmt.Errorf("earnings pattern %q needs (?P<code>...) and (?P<amount>...) groups", source)
+			}
+			if name != "earnings" && pattern.NumSubexp() < 1 {
+				return nil, fmt.Errorf("%s pattern %q needs a capture group", name, source)
+			}
+			patterns.compiled[name] = append(patterns.compiled[name], pattern)
+		}
+	}
+	return &patterns, nil
+}
+
+// Text returns a stub's text, extracting it first when data is a PDF.
+func Text(data []byte) (string, error) {
+	if IsPDF(data) {
+		return PDFText(data)
+	}
+	return string(data), nil
+}
+
+// Parse extracts a stub's figures from its text.
+func (patterns *Patterns) Parse(text string) (Stub, error) {
+	stub := Stub{Earnings: []Earning{}}
+
+	var err error
+	dates := map[string]*string{"pay_date": &stub.PayDate, "period_start": &stub.PeriodStart, "period_end": &stub.PeriodEnd}
+	for name, target := range dates {
+		raw := patterns.find(name, text)
+		if raw == "" {
+			continue
+		}
+		if *target, err = patterns.parseDate(raw); err != nil {
+			return stub, fmt.Errorf("%s: %w", strings.ReplaceAll(name, "_", " "), err)
+		}
+	}
+
+	amounts := map[string]**float64{"gross": &stub.Gross, "net": &stub.Net}
+	for name, target := range amounts {
+		raw := patterns.find(name, text)
+		if raw == "" {
+			continue
+		}
+		amount, err := parseAmount(raw)
+		if err != nil {
+			return stub, fmt.Errorf("%s: %w", name, err)
+		}
+		*target = &amount
+	}
+
+	for _, pattern := range patterns.compiled["earnings"] {
+		for _, match := range pattern.FindAllStringSubmatch(text, -1) {
+			earning := Earning{Code: strings.TrimSpace(match[pattern.SubexpIndex("code")])}
+			if earning.Amount, err = parseAmount(match[pattern.SubexpIndex("amount")]); err != nil {
+				return stub, fmt.Errorf("earnings %s: %w", earning.Code, err)
+			}
+			for group, target := range map[string]**float64{"hours": &earning.Hours, "rate": &earning.Rate} {
+				index := pattern.SubexpIndex(group)
+				if index < 0 || match[index] == "" {
+					continue
+				}
+				value, err := parseAmount(match[index])
+				if err != nil {
+					return stub, fmt.Errorf("earnings %s %s: %w", earning.Code, group, err)
+				}
+				*target = &value
+			}
+			if earning.Hours != nil {
+				stub.TotalHours += *earning.Hours
+			}
+			stub.Earnings = append(stub.Earnings, earning)
+		}
+		if len(stub.Earnings) > 0 {
+			break
+		}
+	}
+
+	if stub.PayDate == "" && stub.PeriodStart == "" && stub.Gross == nil && stub.Net == nil {
+		return stub, fmt.Errorf("no pay stub figures found; check the configured patterns")
+	}
+	return stub, nil
+}
+
+func (patterns *Patterns) find(name, text string) string {
+	for _, pattern := range patterns.compiled[name] {
+		if match := pattern.FindStringSubmatch(text); match != nil {
+			return strings.TrimSpace(match[1])
+		}
+	}
+	return ""
+}
+
+func (patterns *Patterns) parseDate(raw string) (string, error) {
+	for _, layout := range patterns.DateFormats {
+		if date, err := time.Parse(layout, raw); err == nil {
+			return date.Format("2006-01-02"), nil
+		}
+	}
+	return "", fmt.Errorf("unrecognized date %q", raw)
+}
+
+// parseAmount reads money or hours such as "$1,234.56" or "(12.00)".
+func parseAmount(raw string) (float64, error) {
+	clean := strings.NewReplacer("$", "", ",", "", " ", "").Replace(strings.TrimSpace(raw))
+	negative := strings.HasPrefix(clean, "(") && strings.HasSuffix(clean, ")")
+	clean = strings.Trim(clean, "()")
+	value, err := strconv.ParseFloat(clean, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", raw)
+	}
+	if negative {
+		value = -value
+	}
+	return value, nil
+}
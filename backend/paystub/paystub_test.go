@@ -0,0 +1,141 @@
+package paystub
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testPatterns = `
+pay_date:
+  - '(?i)pay\s*date:?\s*([0-9/\-]+|[A-Z][a-z]{2} \d{1,2}, \d{4})'
+period_start:
+  - '(?i)pay\s*period:?\s*([0-9/\-]+)\s*(?:-|to|through)'
+period_end:
+  - '(?i)pay\s*period:?\s*[0-9/\-]+\s*(?:-|to|through)\s*([0-9/\-]+)'
+gross:
+  - '(?i)gross\s*(?:pay)?:?\s*\$?\s*([0-9,]+\.\d{2})'
+net:
+  - '(?i)net\s*(?:pay)?:?\s*\$?\s*([0-9,]+\.\d{2})'
+earnings:
+  - '(?m)^\s*(?P<code>[A-Za-z][A-Za-z /&-]*?)\s+(?P<hours>\d+\.\d{1,2})\s+\$?(?P<rate>\d+\.\d{2,4})\s+\$?(?P<amount>\(?[0-9,]+\.\d{2}\)?)'
+`
+
+func loadPatterns(t *testing.T, yaml string) (*Patterns, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "paystub.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return Load(path)
+}
+
+func TestParse(t *testing.T) {
+	patterns, err := loadPatterns(t, testPatterns)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stub, err := patterns.Parse(`ACME FLIGHT SCHOOL
+Pay Date: 06/20/2025
+Pay Period: 06/09/2025 - 06/22/2025
+Earnings        Hours   Rate     Current
+Flight          2.00    30.00    60.00
+Ground          1.50    25.00    37.50
+Adjustment      0.50    25.00    (12.50)
+Gross Pay: $1,085.00
+Net Pay: $884.12
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stub.PayDate != "2025-06-20" || stub.PeriodStart != "2025-06-09" || stub.PeriodEnd != "2025-06-22" {
+		t.Errorf("dates = %s, %s..%s; want 2025-06-20, 2025-06-09..2025-06-22",
+			stub.PayDate, stub.PeriodStart, stub.PeriodEnd)
+	}
+	if stub.Gross == nil || *stub.Gross != 1085 {
+		t.Errorf("gross = %v, want 1085", stub.Gross)
+	}
+	if stub.Net == nil || *stub.Net != 884.12 {
+		t.Errorf("net = %v, want 884.12", stub.Net)
+	}
+
+	want := []struct {
+		code                string
+		hours, rate, amount float64
+	}{
+		{"Flight", 2, 30, 60},
+		{"Ground", 1.5, 25, 37.5},
+		{"Adjustment", 0.5, 25, -12.5},
+	}
+	if len(stub.Earnings) != len(want) {
+		t.Fatalf("got %d earnings lines, want %d: %+v", len(stub.Earnings), len(want), stub.Earnings)
+	}
+	for i, earning := range stub.Earnings {
+		w := want[i]
+		if earning.Code != w.code || earning.Hours == nil || *earning.Hours != w.hours ||
+			earning.Rate == nil || *earning.Rate != w.rate || earning.Amount != w.amount {
+			t.Errorf("earnings[%d] = %+v, want %+v", i, earning, w)
+		}
+	}
+	if stub.TotalHours != 4 {
+		t.Errorf("total hours = %v, want 4", stub.TotalHours)
+	}
+}
+
+func TestParsePartialAndMissing(t *testing.T) {
+	patterns, err := loadPatterns(t, testPatterns)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a stub with only a pay date and net is still read
+	stub, err := patterns.Parse("Pay Date: Jun 20, 2025\nNet Pay: 84.12\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stub.PayDate != "2025-06-20" || stub.Gross != nil || len(stub.Earnings) != 0 {
+		t.Errorf("partial stub = %+v", stub)
+	}
+
+	if _, err := patterns.Parse("nothing that looks like a pay stub"); err == nil {
+		t.Error("want an error when no figures are found")
+	}
+	if _, err := patterns.Parse("Pay Date: 13/45/2025\n"); err == nil {
+		t.Error("want an error for an unrecognized date")
+	}
+}
+
+func TestLoadRejectsBadPatterns(t *testing.T) {
+	tests := map[string]string{
+		"invalid regexp":      "gross:\n  - '([0-9'\n",
+		"no capture group":    "net:\n  - 'Net Pay'\n",
+		"earnings w/o amount": "earnings:\n  - '(?P<code>\\w+)\\s+\\d+'\n",
+		"not a patterns file": "- just\n- a list\n",
+	}
+	for name, yaml := range tests {
+		if _, err := loadPatterns(t, yaml); err == nil {
+			t.Errorf("%s: want a load error", name)
+		}
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := map[string]float64{
+		"60.00":       60,
+		"$1,234.56":   1234.56,
+		" $ 97.50 ":   97.5,
+		"(12.00)":     -12,
+		"$(1,000.00)": -1000,
+	}
+	for raw, want := range tests {
+		got, err := parseAmount(raw)
+		if err != nil || got != want {
+			t.Errorf("parseAmount(%q) = %v, %v; want %v", raw, got, err, want)
+		}
+	}
+	if _, err := parseAmount("n/a"); err == nil {
+		t.Error(`parseAmount("n/a") want an error`)
+	}
+}
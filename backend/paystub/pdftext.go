@@ -0,0 +1,261 @@
+package paystub
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// IsPDF reports whether data looks like a PDF file.
+func IsPDF(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("%PDF-"))
+}
+
+// PDFText pulls the text drawn by a PDF's content streams, one line per
+// text line. Only uncompressed and FlateDecode streams are read, and glyphs
+// are taken as single-byte characters, which covers stubs exported by
+// payroll systems with standard fonts but not scanned images or CID fonts.
+func PDFText(data []byte) (string, error) {
+	var out strings.Builder
+	rest := data
+	for {
+		start := bytes.Index(rest, []byte("stream"))
+		if start < 0 {
+			break
+		}
+		// skip the "stream" inside "endstream"
+		if start >= 3 && string(rest[start-3:start]) == "end" {
+			rest = rest[start+len("stream"):]
+			continue
+		}
+
+		dictStart := bytes.LastIndex(rest[:start], []byte("obj"))
+		if dictStart < 0 {
+			dictStart = 0
+		}
+		dict := rest[dictStart:start]
+
+		body := rest[start+len("stream"):]
+		body = bytes.TrimPrefix(body, []byte("\r"))
+		body = bytes.TrimPrefix(body, []byte("\n"))
+		end := bytes.Index(body, []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		content := body[:end]
+		rest = body[end+len("endstream"):]
+
+		switch {
+		case bytes.Contains(dict, []byte("/FlateDecode")):
+			reader, err := zlib.NewReader(bytes.NewReader(content))
+			if err != nil {
+				continue
+			}
+			content, err = io.ReadAll(reader)
+			if err != nil && len(content) == 0 {
+				continue
+			}
+		case bytes.Contains(dict, []byte("/Filter")):
+			continue
+		}
+
+		if bytes.Contains(content, []byte("BT")) {
+			contentText(&out, content)
+		}
+	}
+
+	if out.Len() == 0 {
+		return "", fmt.Errorf("no readable text found in PDF")
+	}
+	return out.String(), nil
+}
+
+// contentText interprets the text operators of one content stream.
+func contentText(out *strings.Builder, content []byte) {
+	var operands []string
+	var array []string
+	inArray := false
+	newline := func() {
+		text := out.String()
+		if len(text) > 0 && !strings.HasSuffix(text, "\n") {
+			out.WriteString("\n")
+		}
+	}
+
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case c == '(':
+			text, next := literalString(content, i)
+			i = next
+			if inArray {
+				array = append(array, text)
+			} else {
+				operands = append(operands, text)
+			}
+		case c == '<' && i+1 < len(content) && content[i+1] != '<':
+			end := bytes.IndexByte(content[i:], '>')
+			if end < 0 {
+				return
+			}
+			text := hexString(content[i+1 : i+end])
+			i += end + 1
+			if inArray {
+				array = append(array, text)
+			} else {
+				operands = append(operands, text)
+			}
+		case c == '[':
+			inArray, array = true, nil
+			i++
+		case c == ']':
+			inArray = false
+			i++
+		case c == '%':
+			for i < len(content) && content[i] != '\n' && content[i] != '\r' {
+				i++
+			}
+		case isSpace(c) || c == '<' || c == '>' || c == '{' || c == '}' || c == '/':
+			i++
+			if c == '/' {
+				for i < len(content) && !isSpace(content[i]) && !isDelimiter(content[i]) {
+					i++
+				}
+			}
+		default:
+			start := i
+			for i < len(content) && !isSpace(content[i]) && !isDelimiter(content[i]) {
+				i++
+			}
+			token := string(content[start:i])
+			if inArray {
+				// a large negative kerning adjustment is a word gap
+				if value, err := strconv.ParseFloat(token, 64); err == nil && value < -200 {
+					array = append(array, " ")
+				}
+				continue
+			}
+
+			switch token {
+			case "Tj":
+				if len(operands) > 0 {
+					out.WriteString(operands[len(operands)-1])
+				}
+			case "'", "\"":
+				newline()
+				if len(operands) > 0 {
+					out.WriteString(operands[len(operands)-1])
+				}
+			case "TJ":
+				out.WriteString(strings.Join(array, ""))
+				array = nil
+			case "T*", "ET", "Tm":
+				newline()
+			case "Td", "TD":
+				if len(operands) >= 2 {
+					if y, err := strconv.ParseFloat(operands[len(operands)-1], 64); err == nil && y != 0 {
+						newline()
+					} else {
+						out.WriteString(" ")
+					}
+				}
+			}
+			if _, err := strconv.ParseFloat(token, 64); err == nil {
+				operands = append(operands, token)
+			} else {
+				operands = operands[:0]
+			}
+		}
+	}
+	newline()
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+// literalString reads a (...) string starting at content[start], returning
+// its text and the index just past it.
+func literalString(content []byte, start int) (string, int) {
+	var b strings.Builder
+	depth := 0
+	i := start
+	for i < len(content) {
+		c := content[i]
+		switch c {
+		case '(':
+			if depth > 0 {
+				b.WriteByte(c)
+			}
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return b.String(), i + 1
+			}
+			b.WriteByte(c)
+		case '\\':
+			i++
+			if i >= len(content) {
+				break
+			}
+			switch e := content[i]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'b', 'f':
+			case '\r', '\n':
+				if e == '\r' && i+1 < len(content) && content[i+1] == '\n' {
+					i++
+				}
+			default:
+				if e >= '0' && e <= '7' {
+					end := i
+					for end < len(content) && end < i+3 && content[end] >= '0' && content[end] <= '7' {
+						end++
+					}
+					value, _ := strconv.ParseUint(string(content[i:end]), 8, 8)
+					b.WriteRune(rune(value))
+					i = end - 1
+				} else {
+					b.WriteByte(e)
+				}
+			}
+		default:
+			b.WriteRune(rune(c))
+		}
+		i++
+	}
+	return b.String(), i
+}
+
+func hexString(hex []byte) string {
+	digits := make([]byte, 0, len(hex))
+	for _, c := range hex {
+		if !isSpace(c) {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	var b strings.Builder
+	for i := 0; i+1 < len(digits); i += 2 {
+		value, err := strconv.ParseUint(string(digits[i:i+2]), 16, 8)
+		if err != nil {
+			continue
+		}
+		b.WriteRune(rune(value))
+	}
+	return b.String()
+}
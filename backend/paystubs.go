@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+
+	db "github.com/theHousedev/pay-log/backend/database"
+	"github.com/theHousedev/pay-log/backend/paystub"
+)
+
+// maxStubUpload caps an uploaded pay stub.
+const maxStubUpload = 10 << 20
+
+// stubPatterns is loaded from the file named in cfg.yaml. Without one,
+// pay stub uploads are refused.
+var stubPatterns *paystub.Patterns
+
+// stubReconciliation compares a stub with what was logged for its period.
+type stubReconciliation struct {
+	PeriodID        int      `json:"period_id"`
+	BeginDate       string   `json:"begin_date"`
+	EndDate         string   `json:"end_date"`
+	ExpectedGross   float64  `json:"expected_gross"`
+	ActualGross     *float64 `json:"actual_gross,omitempty"`
+	GrossDifference *float64 `json:"gross_difference,omitempty"`
+	LoggedHours     float64  `json:"logged_hours"`
+	StubHours       float64  `json:"stub_hours"`
+	HoursDifference float64  `json:"hours_difference"`
+}
+
+func roundCents(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// setupPayStubs lists uploaded pay stubs.
+func setupPayStubs(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		stubs, err := database.GetPayStubs()
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to get pay stubs: %v", err),
+			})
+			return
+		}
+
+		data, _ := json.Marshal(stubs)
+		toJSON(w, db.Response{
+			Status:  "OK",
+			Message: "Pay stubs retrieved",
+			Data:    data,
+		})
+	}
+}
+
+// setupPayStubUpload reads a pay stub (text PDF or plain text, as the
+// multipart "file" or the raw body), matches it to its pay period and
+// records the actual gross and net there. A stub already on file for the
+// same period, pay date and gross is refused. With ?dry_run=true the stub
+// is only parsed and reconciled, nothing is saved.
+func setupPayStubUpload(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if stubPatterns == nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: "No pay stub patterns configured",
+			})
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxStubUpload)
+		var body io.Reader = r.Body
+		var filename *string
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			file, header, err := r.FormFile("file")
+			if err != nil {
+				toJSON(w, db.Response{
+					Status:  "ERROR",
+					Message: fmt.Sprintf("Missing pay stub file: %v", err),
+				})
+				return
+			}
+			defer file.Close()
+			body = file
+			filename = &header.Filename
+		}
+
+		raw, err := io.ReadAll(body)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to read pay stub: %v", err),
+			})
+			return
+		}
+
+		text, err := paystub.Text(raw)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to read pay stub: %v", err),
+			})
+			return
+		}
+		stub, err := stubPatterns.Parse(text)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to parse pay stub: %v", err),
+			})
+			return
+		}
+
+		period, err := database.MatchPayPeriod(stub.PeriodStart, stub.PayDate)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to match pay period: %v", err),
+			})
+			return
+		}
+
+		totals, err := database.CalculatePeriodTotals(period.ID, period.BeginDate, period.EndDate)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to calculate period totals: %v", err),
+			})
+			return
+		}
+		reconciliation := stubReconciliation{
+			PeriodID:      period.ID,
+			BeginDate:     strings.Split(period.BeginDate, "T")[0],
+			EndDate:       strings.Split(period.EndDate, "T")[0],
+			ExpectedGross: roundCents(totals["total_gross"].(float64)),
+			ActualGross:   stub.Gross,
+			LoggedHours:   roundCents(totals["total_hours"].(float64)),
+			StubHours:     roundCents(stub.TotalHours),
+		}
+		reconciliation.HoursDifference = roundCents(reconciliation.StubHours - reconciliation.LoggedHours)
+		if stub.Gross != nil {
+			difference := roundCents(*stub.Gross - reconciliation.ExpectedGross)
+			reconciliation.GrossDifference = &difference
+		}
+
+		result := map[string]interface{}{
+			"stub":           stub,
+			"reconciliation": reconciliation,
+		}
+		if r.URL.Query().Get("dry_run") == "true" {
+			data, _ := json.Marshal(result)
+			toJSON(w, db.Response{
+				Status:  "OK",
+				Message: "Pay stub parsed (dry run, nothing saved)",
+				Data:    data,
+			})
+			return
+		}
+
+		record := db.PayStub{
+			PayPeriodID: &period.ID,
+			Gross:       stub.Gross,
+			Net:         stub.Net,
+			TotalHours:  stub.TotalHours,
+			PayDate:     optionalString(stub.PayDate),
+			PeriodStart: optionalString(stub.PeriodStart),
+			PeriodEnd:   optionalString(stub.PeriodEnd),
+			Filename:    filename,
+			RawText:     text,
+		}
+		for _, earning := range stub.Earnings {
+			record.Earnings = append(record.Earnings, db.PayStubEarning(earning))
+		}
+
+		gross, net := period.GrossActual, period.NetActual
+		if stub.Gross != nil {
+			gross = stub.Gross
+		}
+		if stub.Net != nil {
+			net = stub.Net
+		}
+		response := database.RecordPayStub(record, db.Paycheck{
+			ID:          period.ID,
+			PayDate:     stub.PayDate,
+			GrossActual: gross,
+			NetActual:   net,
+		})
+		if response.Status != "OK" {
+			toJSON(w, response)
+			return
+		}
+		var saved struct {
+			StubID int `json:"stub_id"`
+		}
+		if err := json.Unmarshal(response.Data, &saved); err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Pay stub recorded but its ID was unreadable: %v", err),
+			})
+			return
+		}
+		result["stub_id"] = saved.StubID
+
+		data, _ := json.Marshal(result)
+		toJSON(w, db.Response{
+			Status:  "OK",
+			Message: fmt.Sprintf("Pay stub recorded for pay period ID=%d", period.ID),
+			Data:    data,
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	db "github.com/theHousedev/pay-log/backend/database"
+	"github.com/theHousedev/pay-log/backend/report"
+)
+
+// setupPeriodReport downloads a printable summary of one pay period as a
+// PDF: its dates, every entry (oldest first), hours and pay by category, the rates used,
+// the expected gross and whatever actual pay has been recorded.
+func setupPeriodReport(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		periodID, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: "Invalid pay period ID",
+			})
+			return
+		}
+
+		period, err := database.GetPayPeriod(periodID)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to get pay period: %v", err),
+			})
+			return
+		}
+
+		entries, err := database.FetchEntries(period.BeginDate, period.EndDate)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to get entries: %v", err),
+			})
+			return
+		}
+		sort.SliceStable(entries, func(i, j int) bool {
+			if entries[i].Date != entries[j].Date {
+				return entries[i].Date < entries[j].Date
+			}
+			return entries[i].Time < entries[j].Time
+		})
+
+		totals, err := database.CalculatePeriodTotals(period.ID, period.BeginDate, period.EndDate)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to calculate totals: %v", err),
+			})
+			return
+		}
+
+		rates, err := database.RatesInEffect(period.BeginDate, period.EndDate)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to get pay rates: %v", err),
+			})
+			return
+		}
+
+		attachment(w, "application/pdf", fmt.Sprintf("period-%s-%s.pdf", period.BeginDate, period.EndDate))
+		w.Write(periodPDF(period, entries, totals, rates))
+	}
+}
+
+func optionalHours(hours *float64) string {
+	if hours == nil || *hours == 0 {
+		return ""
+	}
+	return formatHours(*hours)
+}
+
+func money(amount float64) string {
+	return "$" + strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+func periodPDF(period db.Paycheck, entries []db.Entry, totals map[string]interface{}, rates []db.PayRate) []byte {
+	doc := report.NewPDF()
+	doc.Heading(fmt.Sprintf("Pay Period Report: %s to %s", period.BeginDate, period.EndDate))
+	doc.Linef("Pay date:      %s", period.PayDate)
+	doc.Linef("Status:        %s", period.Status)
+	doc.Linef("Generated:     %s", time.Now().In(time.Local).Format("2006-01-02"))
+	doc.Blank()
+
+	doc.Heading(fmt.Sprintf("%-10s  %-7s  %6s  %6s  %6s  %6s  %5s  %s",
+		"Date", "Type", "Flight", "Ground", "Sim", "Admin", "Rides", "Customer / Notes"))
+	doc.Rule()
+	for _, entry := range entries {
+		rides := ""
+		if entry.RideCount != nil && *entry.RideCount > 0 {
+			rides = strconv.Itoa(*entry.RideCount)
+		}
+		var remarks []string
+		if entry.Meeting {
+			remarks = append(remarks, "meeting")
+		}
+		for _, text := range []*string{entry.Customer, entry.Notes} {
+			if text != nil && *text != "" {
+				remarks = append(remarks, *text)
+			}
+		}
+		doc.Linef("%-10s  %-7s  %6s  %6s  %6s  %6s  %5s  %s",
+			entryDay(entry), entry.Type,
+			optionalHours(entry.FlightHours), optionalHours(entry.GroundHours),
+			optionalHours(entry.SimHours), optionalHours(entry.AdminHours),
+			rides, strings.Join(remarks, "; "))
+	}
+	if len(entries) == 0 {
+		doc.Line("No entries logged.")
+	}
+	doc.Blank()
+
+	doc.Heading(fmt.Sprintf("%-10s  %8s  %7s  %12s", "Category", "Hours", "Count", "Pay"))
+	doc.Rule()
+	categories := totals["pay_by_category"].(map[string]*db.CategoryPay)
+	for _, name := range db.PayCategories {
+		category := categories[name]
+		if category.Count == 0 && category.Pay == 0 {
+			continue
+		}
+		doc.Linef("%-10s  %8s  %7d  %12s", name, formatHours(category.Hours), category.Count, money(category.Pay))
+	}
+	if overtime := totals["overtime_pay"].(float64); overtime != 0 {
+		doc.Linef("%-10s  %8s  %7s  %12s", "overtime", formatHours(totals["overtime_hours"].(float64)), "", money(overtime))
+	}
+	for _, premium := range totals["premiums"].([]db.PremiumLine) {
+		doc.Linef("%-10s  %8s  %7d  %12s", premium.Name, "", premium.Entries, money(premium.Amount))
+	}
+	doc.Rule()
+	doc.Linef("%-10s  %8s  %7s  %12s", "total", formatHours(totals["total_hours"].(float64)), "",
+		money(totals["total_gross"].(float64)))
+	doc.Blank()
+
+	doc.Heading("Rates Used")
+	doc.Rule()
+	for _, rate := range rates {
+		doc.Linef("Effective %s: flight %s, ground %s, sim %s, admin %s, misc %s",
+			rate.EffectiveDate, money(rate.Hourly("flight")), money(rate.Hourly("ground")),
+			money(rate.Hourly("sim")), money(rate.Hourly("admin")), money(rate.Hourly("misc")))
+		rides := fmt.Sprintf("%s hr credit", formatHours(rate.RideCredit()))
+		if rate.RideCreditAmount != nil {
+			rides = money(*rate.RideCreditAmount) + " each"
+		}
+		meetings := "logged hours"
+		if rate.MeetingAmount != nil {
+			meetings = money(*rate.MeetingAmount) + " each"
+		} else if rate.MeetingHours != nil {
+			meetings = fmt.Sprintf("%s hr credit", formatHours(*rate.MeetingHours))
+		}
+		overtime := "none"
+		if rate.OvertimeThreshold != nil {
+			overtime = fmt.Sprintf("%sx over %s hr/week", strconv.FormatFloat(rate.OvertimeMultiplier, 'f', -1, 64),
+				formatHours(*rate.OvertimeThreshold))
+		}
+		doc.Linef("  rides %s, meetings %s, overtime %s", rides, meetings, overtime)
+	}
+	doc.Blank()
+
+	expected := totals["total_gross"].(float64)
+	doc.Heading("Pay")
+	doc.Rule()
+	doc.Linef("Expected gross:     %12s", money(expected))
+	if net := estimateNet(expected); net != nil {
+		doc.Linef("Estimated net:      %12s", money(*net))
+	}
+	if period.GrossActual != nil {
+		doc.Linef("Actual gross:       %12s", money(*period.GrossActual))
+		doc.Linef("Gross difference:   %12s", money(roundCents(*period.GrossActual-expected)))
+	} else {
+		doc.Line("Actual gross:       not recorded")
+	}
+	if period.NetActual != nil {
+		doc.Linef("Actual net:         %12s", money(*period.NetActual))
+	} else {
+		doc.Line("Actual net:         not recorded")
+	}
+	return doc.Bytes()
+}
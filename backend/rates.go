@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	db "github.com/theHousedev/pay-log/backend/database"
+)
+
+// setupRates lists pay rates (GET) or adds a new effective-dated rate,
+// including its overtime rule (POST).
+func setupRates(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			rates, err := database.GetRates()
+			if err != nil {
+				toJSON(w, db.Response{
+					Status:  "ERROR",
+					Message: fmt.Sprintf("Failed to get rates: %v", err),
+				})
+				return
+			}
+
+			data, _ := json.Marshal(rates)
+			toJSON(w, db.Response{
+				Status:  "OK",
+				Message: "Pay rates retrieved",
+				Data:    data,
+			})
+
+		case http.MethodPost:
+			var rate db.PayRate
+			if err := json.NewDecoder(r.Body).Decode(&rate); err != nil {
+				toJSON(w, db.Response{
+					Status:  "ERROR",
+					Message: "Invalid JSON format",
+				})
+				return
+			}
+			toJSON(w, database.CreatePayRate(rate))
+
+		default:
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// setupPremiums lists premium rules (GET) or adds one (POST).
+func setupPremiums(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			premiums, err := database.GetPremiums("", "")
+			if err != nil {
+				toJSON(w, db.Response{
+					Status:  "ERROR",
+					Message: fmt.Sprintf("Failed to get premiums: %v", err),
+				})
+				return
+			}
+
+			data, _ := json.Marshal(premiums)
+			toJSON(w, db.Response{
+				Status:  "OK",
+				Message: "Premiums retrieved",
+				Data:    data,
+			})
+
+		case http.MethodPost:
+			var premium db.Premium
+			if err := json.NewDecoder(r.Body).Decode(&premium); err != nil {
+				toJSON(w, db.Response{
+					Status:  "ERROR",
+					Message: "Invalid JSON format",
+				})
+				return
+			}
+			toJSON(w, database.CreatePremium(premium))
+
+		default:
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Package report renders plain-text reports as minimal PDF documents.
+//
+// Only what the pay log needs is supported: US Letter pages of monospaced
+// (Courier) text with an optional bold heading line. Long documents are
+// split across pages automatically.
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	pageWidth    = 612
+	pageHeight   = 792
+	margin       = 50
+	fontSize     = 9
+	lineHeight   = 12
+	linesPerPage = (pageHeight - 2*margin) / lineHeight
+
+	// LineWidth is how many Courier characters fit across a page.
+	LineWidth = (pageWidth - 2*margin) * 10 / (fontSize * 6)
+)
+
+type line struct {
+	text string
+	bold bool
+}
+
+type PDF struct {
+	lines []line
+}
+
+func NewPDF() *PDF {
+	return &PDF{}
+}
+
+// Heading adds a bold line.
+func (pdf *PDF) Heading(text string) {
+	pdf.lines = append(pdf.lines, line{text: text, bold: true})
+}
+
+// Line adds a line of regular text. Text wider than the page is wrapped.
+func (pdf *PDF) Line(text string) {
+	runes := []rune(text)
+	for len(runes) > LineWidth {
+		pdf.lines = append(pdf.lines, line{text: string(runes[:LineWidth])})
+		runes = runes[LineWidth:]
+	}
+	pdf.lines = append(pdf.lines, line{text: string(runes)})
+}
+
+// Linef adds a formatted line of regular text.
+func (pdf *PDF) Linef(format string, args ...interface{}) {
+	pdf.Line(fmt.Sprintf(format, args...))
+}
+
+// Blank adds an empty line.
+func (pdf *PDF) Blank() {
+	pdf.lines = append(pdf.lines, line{})
+}
+
+// Rule adds a horizontal separator made of dashes.
+func (pdf *PDF) Rule() {
+	pdf.Line(strings.Repeat("-", LineWidth))
+}
+
+// Bytes renders the document.
+func (pdf *PDF) Bytes() []byte {
+	var pages [][]line
+	for start := 0; start < len(pdf.lines) || start == 0; start += linesPerPage {
+		end := start + linesPerPage
+		if end > len(pdf.lines) {
+			end = len(pdf.lines)
+		}
+		pages = append(pages, pdf.lines[start:end])
+	}
+
+	// object layout: 1 catalog, 2 page tree, 3 regular font, 4 bold font,
+	// then a page object and content stream per page
+	var objects []string
+	objects = append(objects, "<< /Type /Catalog /Pages 2 0 R >>")
+
+	var kids []string
+	for i := range pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 5+i*2))
+	}
+	objects = append(objects, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>",
+		strings.Join(kids, " "), len(pages)))
+	objects = append(objects,
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>",
+	)
+
+	for i, page := range pages {
+		var content bytes.Buffer
+		content.WriteString("BT\n")
+		fmt.Fprintf(&content, "%d TL\n", lineHeight)
+		fmt.Fprintf(&content, "%d %d Td\n", margin, pageHeight-margin)
+		for _, l := range page {
+			font := "F1"
+			if l.bold {
+				font = "F2"
+			}
+			fmt.Fprintf(&content, "/%s %d Tf\n(%s) Tj T*\n", font, fontSize, escape(l.text))
+		}
+		content.WriteString("ET")
+
+		objects = append(objects, fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] "+
+				"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 6+i*2))
+		objects = append(objects, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream",
+			content.Len(), content.String()))
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(objects)+1, xref)
+	return out.Bytes()
+}
+
+// escape makes text safe inside a PDF string literal. Characters outside
+// Latin-1 have no glyph in the standard fonts and are replaced with '?'.
+func escape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32:
+			b.WriteByte(' ')
+		case r > 255:
+			b.WriteByte('?')
+		default:
+			b.WriteByte(byte(r))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/theHousedev/pay-log/backend/database"
+)
+
+// setupMonthlyStats returns the monthly_stats rollups for ?year=, defaulting
+// to the current year.
+func setupMonthlyStats(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		year := time.Now().In(time.Local).Year()
+		if raw := r.URL.Query().Get("year"); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil {
+				toJSON(w, db.Response{
+					Status:  "ERROR",
+					Message: fmt.Sprintf("Invalid year '%s'", raw),
+				})
+				return
+			}
+			year = parsed
+		}
+
+		stats, err := database.GetMonthlyStats(year)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to get monthly stats: %v", err),
+			})
+			return
+		}
+
+		data, _ := json.Marshal(stats)
+		toJSON(w, db.Response{
+			Status:  "OK",
+			Message: fmt.Sprintf("Monthly stats retrieved for %d", year),
+			Data:    data,
+		})
+	}
+}
+
+// setupYearSummary returns year-to-date earnings and hours for ?year=, as of
+// ?date= (default today, or 31 Dec for past years), compared with the same
+// point in the previous year. Years are counted by pay date; see
+// GetYearSummary.
+func setupYearSummary(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		today := time.Now().In(time.Local)
+		year := today.Year()
+		if raw := r.URL.Query().Get("year"); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil {
+				toJSON(w, db.Response{
+					Status:  "ERROR",
+					Message: fmt.Sprintf("Invalid year '%s'", raw),
+				})
+				return
+			}
+			year = parsed
+		}
+
+		asOf := today
+		if raw := r.URL.Query().Get("date"); raw != "" {
+			parsed, err := time.Parse("2006-01-02", raw)
+			if err != nil {
+				toJSON(w, db.Response{
+					Status:  "ERROR",
+					Message: fmt.Sprintf("Invalid date '%s', expected YYYY-MM-DD", raw),
+				})
+				return
+			}
+			asOf = parsed
+		}
+		if endOfYear := time.Date(year, time.December, 31, 0, 0, 0, 0, time.Local); asOf.After(endOfYear) {
+			asOf = endOfYear
+		}
+
+		summary, err := database.GetYearSummary(year, asOf.Format("2006-01-02"))
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to build summary: %v", err),
+			})
+			return
+		}
+
+		previous, err := database.GetYearSummary(year-1, asOf.AddDate(-1, 0, 0).Format("2006-01-02"))
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to build previous year summary: %v", err),
+			})
+			return
+		}
+
+		responseData := map[string]interface{}{
+			"summary": summary,
+			"year_over_year": map[string]interface{}{
+				"previous_year":           previous.Year,
+				"previous_as_of":          previous.AsOf,
+				"previous_expected_gross": previous.ExpectedGross,
+				"previous_actual_gross":   previous.ActualGross,
+				"previous_total_hours":    previous.TotalHours,
+				"expected_gross_change":   summary.ExpectedGross - previous.ExpectedGross,
+				"expected_gross_pct":      percentChange(previous.ExpectedGross, summary.ExpectedGross),
+				"actual_gross_change":     summary.ActualGross - previous.ActualGross,
+				"actual_gross_pct":        percentChange(previous.ActualGross, summary.ActualGross),
+				"total_hours_change":      summary.TotalHours - previous.TotalHours,
+				"total_hours_pct":         percentChange(previous.TotalHours, summary.TotalHours),
+			},
+		}
+
+		data, _ := json.Marshal(responseData)
+		toJSON(w, db.Response{
+			Status:  "OK",
+			Message: fmt.Sprintf("Summary retrieved for %d", year),
+			Data:    data,
+		})
+	}
+}
+
+// percentChange is nil when there is nothing to compare against.
+func percentChange(from, to float64) *float64 {
+	if from == 0 {
+		return nil
+	}
+	change := (to - from) / from * 100
+	return &change
+}
+
+// maxSeriesBuckets keeps a typo like bucket=day&view=all from running
+// thousands of totals queries.
+const maxSeriesBuckets = 1000
+
+type seriesPoint struct {
+	Start       string  `json:"start"`
+	End         string  `json:"end"`
+	FlightHours float64 `json:"flight_hours"`
+	GroundHours float64 `json:"ground_hours"`
+	SimHours    float64 `json:"sim_hours"`
+	AdminHours  float64 `json:"admin_hours"`
+	Rides       int     `json:"rides"`
+	Meetings    int     `json:"meetings"`
+	TotalHours  float64 `json:"total_hours"`
+	Gross       float64 `json:"gross"`
+}
+
+// seriesBuckets splits [start, end] into consecutive day, week, pay period
+// or month buckets. The first and last buckets are clipped to the range.
+func seriesBuckets(start, end time.Time, bucket string, week WeekSettings) ([][2]time.Time, error) {
+	var buckets [][2]time.Time
+	for cursor := start; !cursor.After(end); {
+		var bucketStart, bucketEnd time.Time
+		switch bucket {
+		case "day":
+			bucketStart, bucketEnd = cursor, cursor
+		case "week":
+			bucketStart, bucketEnd = weekBounds(cursor, week.Start, false)
+		case "period":
+			bucketStart, bucketEnd = db.PeriodBounds(cursor)
+		case "month":
+			bucketStart = time.Date(cursor.Year(), cursor.Month(), 1, 0, 0, 0, 0, time.UTC)
+			bucketEnd = bucketStart.AddDate(0, 1, -1)
+		default:
+			return nil, fmt.Errorf("Invalid bucket. Use: day, week, period, or month")
+		}
+
+		if bucketStart.Before(start) {
+			bucketStart = start
+		}
+		if bucketEnd.After(end) {
+			bucketEnd = end
+		}
+		buckets = append(buckets, [2]time.Time{bucketStart, bucketEnd})
+		if len(buckets) > maxSeriesBuckets {
+			return nil, fmt.Errorf("Too many buckets; use a larger bucket or a shorter range")
+		}
+		cursor = bucketEnd.AddDate(0, 0, 1)
+	}
+	return buckets, nil
+}
+
+// setupTimeSeries returns hours and earnings per bucket over a view's date
+// range, one point per bucket, for charting.
+func setupTimeSeries(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		bucket := r.URL.Query().Get("bucket")
+		if bucket == "" {
+			bucket = "day"
+		}
+
+		view, beginDate, endDate, err := requestRange(database, r)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: err.Error(),
+			})
+			return
+		}
+		week, _ := weekSettingsFor(r)
+		if beginDate == "all" || endDate == "all" {
+			firstDate, lastDate, err := database.EntryDateRange()
+			if err != nil {
+				toJSON(w, db.Response{
+					Status:  "ERROR",
+					Message: fmt.Sprintf("Failed to get entry range: %v", err),
+				})
+				return
+			}
+			beginDate, endDate = keepIfOpen(beginDate, firstDate), keepIfOpen(endDate, lastDate)
+		}
+
+		start, _ := time.Parse("2006-01-02", beginDate)
+		end, _ := time.Parse("2006-01-02", endDate)
+		buckets, err := seriesBuckets(start, end, bucket, week)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: err.Error(),
+			})
+			return
+		}
+
+		points := make([]seriesPoint, 0, len(buckets))
+		for _, b := range buckets {
+			totals, err := database.CalculateRangeTotals(b[0].Format("2006-01-02"), b[1].Format("2006-01-02"))
+			if err != nil {
+				toJSON(w, db.Response{
+					Status:  "ERROR",
+					Message: fmt.Sprintf("Failed to calculate totals: %v", err),
+				})
+				return
+			}
+			points = append(points, seriesPoint{
+				Start:       b[0].Format("2006-01-02"),
+				End:         b[1].Format("2006-01-02"),
+				FlightHours: totals["flight_hours"].(float64),
+				GroundHours: totals["ground_hours"].(float64),
+				SimHours:    totals["sim_hours"].(float64),
+				AdminHours:  totals["admin_hours"].(float64),
+				Rides:       totals["total_rides"].(int),
+				Meetings:    totals["meetings"].(int),
+				TotalHours:  totals["total_hours"].(float64),
+				Gross:       totals["total_gross"].(float64),
+			})
+		}
+
+		responseData := map[string]interface{}{
+			"view":   view,
+			"bucket": bucket,
+			"start":  beginDate,
+			"end":    endDate,
+			"points": points,
+		}
+
+		data, _ := json.Marshal(responseData)
+		toJSON(w, db.Response{
+			Status:  "OK",
+			Message: fmt.Sprintf("Series retrieved for %s view by %s", view, bucket),
+			Data:    data,
+		})
+	}
+}
+
+// setupProjection projects period-end and year-end gross as of ?date=.
+// ?pace_days= sets the pacing window; ?period_target= and ?year_target=
+// are gross targets to measure the projection against, defaulting to the
+// active gross goals.
+func setupProjection(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := r.URL.Query()
+		date := query.Get("date")
+		if date == "" {
+			date = time.Now().In(time.Local).Format("2006-01-02")
+		}
+
+		paceDays := 0
+		if raw := query.Get("pace_days"); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed <= 0 {
+				toJSON(w, db.Response{
+					Status:  "ERROR",
+					Message: fmt.Sprintf("Invalid pace_days '%s'", raw),
+				})
+				return
+			}
+			paceDays = parsed
+		}
+
+		periodTarget, err := optionalFloat(query.Get("period_target"))
+		if err != nil {
+			toJSON(w, db.Response{Status: "ERROR", Message: err.Error()})
+			return
+		}
+		yearTarget, err := optionalFloat(query.Get("year_target"))
+		if err != nil {
+			toJSON(w, db.Response{Status: "ERROR", Message: err.Error()})
+			return
+		}
+
+		for scope, target := range map[string]**float64{"period": &periodTarget, "year": &yearTarget} {
+			if *target != nil {
+				continue
+			}
+			goal, err := database.GetActiveGoal(scope, "gross", date)
+			if err != nil {
+				toJSON(w, db.Response{
+					Status:  "ERROR",
+					Message: fmt.Sprintf("Failed to get goals: %v", err),
+				})
+				return
+			}
+			if goal != nil {
+				*target = &goal.Target
+			}
+		}
+
+		period, year, err := database.ProjectPeriodAndYear(date, paceDays, periodTarget, yearTarget)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to build projection: %v", err),
+			})
+			return
+		}
+
+		data, _ := json.Marshal(map[string]interface{}{
+			"period": period,
+			"year":   year,
+		})
+		toJSON(w, db.Response{
+			Status:  "OK",
+			Message: fmt.Sprintf("Projection retrieved as of %s", date),
+			Data:    data,
+		})
+	}
+}
+
+func optionalFloat(raw string) (*float64, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid number '%s'", raw)
+	}
+	return &value, nil
+}
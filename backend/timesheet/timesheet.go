@@ -0,0 +1,380 @@
+// Package timesheet lays out logged work as the rows of an employer's
+// timesheet, using a configurable template of columns, grouping and
+// rounding, and writes it as CSV or XLSX.
+package timesheet
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Fields a column can show.
+var fields = map[string]bool{
+	"date": true, "weekday": true, "category": true, "hours": true, "count": true,
+	"rate": true, "amount": true, "customers": true, "notes": true, "tail_numbers": true,
+}
+
+var numericFields = map[string]bool{"hours": true, "count": true, "rate": true, "amount": true}
+
+// categoryOrder is the order rows of the same day are listed in.
+var categoryOrder = []string{"flight", "ground", "sim", "admin", "rides", "misc", "meeting"}
+
+type Column struct {
+	Header string `yaml:"header"`
+	Field  string `yaml:"field"`
+}
+
+// Rounding rounds each row's hours to a multiple of Increment minutes (6
+// for tenths, 15 for quarter hours), as the engine's rounding in cfg.yaml
+// does. Mode is nearest, up or down. It changes the hours shown only;
+// amounts and rates are always what the pay engine paid, so a rounded row
+// need not multiply out to its amount. Rounding that affects pay belongs
+// in the engine's config.
+type Rounding struct {
+	Increment float64 `yaml:"increment"`
+	Mode      string  `yaml:"mode"`
+}
+
+type Template struct {
+	SheetName  string            `yaml:"sheet_name"`
+	DateFormat string            `yaml:"date_format"`
+	GroupBy    string            `yaml:"group_by"`
+	Rounding   Rounding          `yaml:"rounding"`
+	Decimals   int               `yaml:"decimals"`
+	Categories map[string]string `yaml:"categories"`
+	Columns    []Column          `yaml:"columns"`
+	Totals     bool              `yaml:"totals"`
+}
+
+// Line is the pay one entry earned in one category, as computed by the
+// pay engine.
+type Line struct {
+	EntryID    int
+	Date       string // YYYY-MM-DD
+	Category   string
+	Hours      float64
+	Count      int
+	Pay        float64
+	Customer   string
+	Notes      string
+	TailNumber string
+}
+
+// Adjustment is pay not tied to one entry, such as overtime or a premium,
+// listed after the entry rows.
+type Adjustment struct {
+	Name   string
+	Hours  float64
+	Count  int
+	Amount float64
+}
+
+// Sheet is a rendered timesheet. Cells hold a string, an int or a float64.
+type Sheet struct {
+	Name   string
+	Header []string
+	Rows   [][]interface{}
+}
+
+// Default is used when no template file is configured.
+func Default() *Template {
+	template := &Template{
+		Columns: []Column{
+			{Header: "Date", Field: "date"},
+			{Header: "Category", Field: "category"},
+			{Header: "Hours", Field: "hours"},
+			{Header: "Rate", Field: "rate"},
+			{Header: "Amount", Field: "amount"},
+			{Header: "Notes", Field: "notes"},
+		},
+		Totals: true,
+	}
+	template.setDefaults()
+	return template
+}
+
+// Load reads a timesheet template from a YAML file.
+func Load(path string) (*Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read timesheet template: %w", err)
+	}
+
+	var template Template
+	if err := yaml.Unmarshal(data, &template); err != nil {
+		return nil, fmt.Errorf("failed to parse timesheet template: %w", err)
+	}
+	template.setDefaults()
+
+	switch template.GroupBy {
+	case "entry", "day", "day_category":
+	default:
+		return nil, fmt.Errorf("invalid group_by %q: use entry, day or day_category", template.GroupBy)
+	}
+	switch template.Rounding.Mode {
+	case "nearest", "up", "down":
+	default:
+		return nil, fmt.Errorf("invalid rounding mode %q: use nearest, up or down", template.Rounding.Mode)
+	}
+	// catches templates still written in hours (0.1), which would
+	// otherwise round to fractions of a minute
+	if increment := template.Rounding.Increment; increment != 0 && increment < 1 {
+		return nil, fmt.Errorf("rounding increment is in minutes (6 for tenths of an hour), not %v", increment)
+	}
+	if len(template.Columns) == 0 {
+		return nil, fmt.Errorf("timesheet template has no columns")
+	}
+	for _, column := range template.Columns {
+		if !fields[column.Field] {
+			return nil, fmt.Errorf("column %q has unknown field %q", column.Header, column.Field)
+		}
+	}
+	return &template, nil
+}
+
+func (template *Template) setDefaults() {
+	if template.SheetName == "" {
+		template.SheetName = "Timesheet"
+	}
+	if template.DateFormat == "" {
+		template.DateFormat = "2006-01-02"
+	}
+	if template.GroupBy == "" {
+		template.GroupBy = "day_category"
+	}
+	if template.Rounding.Mode == "" {
+		template.Rounding.Mode = "nearest"
+	}
+	if template.Decimals == 0 {
+		template.Decimals = 2
+	}
+}
+
+// Round applies the template's rounding rule to hours.
+func (template *Template) Round(hours float64) float64 {
+	increment := template.Rounding.Increment
+	if increment <= 0 || hours == 0 {
+		return hours
+	}
+	// work in minutes, nudged so float error (0.1 h = 6.000000000000001
+	// min) can't move an exact multiple a step
+	steps := hours * 60 / increment
+	switch template.Rounding.Mode {
+	case "up":
+		steps = math.Ceil(steps - 1e-9)
+	case "down":
+		steps = math.Floor(steps + 1e-9)
+	default:
+		steps = math.Round(steps)
+	}
+	return math.Round(steps*increment/60*1e6) / 1e6
+}
+
+type row struct {
+	date       string
+	categories []string
+	hours      float64
+	count      int
+	pay        float64
+	customers  []string
+	notes      []string
+	tails      []string
+}
+
+func appendUnique(values []string, value string) []string {
+	if value == "" {
+		return values
+	}
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+func categoryRank(category string) int {
+	for i, name := range categoryOrder {
+		if name == category {
+			return i
+		}
+	}
+	return len(categoryOrder)
+}
+
+// Build groups lines into timesheet rows, oldest first, rounding each
+// row's hours for display, then appends adjustments and a totals row when
+// the template asks for one. Amounts and rates are left as the lines priced
+// them, so the total matches the pay engine's gross; the rate is the one
+// the engine paid on the unrounded hours.
+func (template *Template) Build(lines []Line, adjustments []Adjustment) Sheet {
+	sheet := Sheet{Name: template.SheetName, Rows: [][]interface{}{}}
+	for _, column := range template.Columns {
+		sheet.Header = append(sheet.Header, column.Header)
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		if lines[i].Date != lines[j].Date {
+			return lines[i].Date < lines[j].Date
+		}
+		return categoryRank(lines[i].Category) < categoryRank(lines[j].Category)
+	})
+
+	var rows []*row
+	byKey := map[string]*row{}
+	for _, line := range lines {
+		var key string
+		switch template.GroupBy {
+		case "entry":
+			key = fmt.Sprintf("%d|%s", line.EntryID, line.Category)
+		case "day":
+			key = line.Date
+		default:
+			key = line.Date + "|" + line.Category
+		}
+		group, ok := byKey[key]
+		if !ok {
+			group = &row{date: line.Date}
+			byKey[key] = group
+			rows = append(rows, group)
+		}
+		group.categories = appendUnique(group.categories, template.categoryName(line.Category))
+		group.hours += line.Hours
+		group.count += line.Count
+		group.pay += line.Pay
+		group.customers = appendUnique(group.customers, line.Customer)
+		group.notes = appendUnique(group.notes, line.Notes)
+		group.tails = appendUnique(group.tails, line.TailNumber)
+	}
+
+	var totalHours, totalAmount float64
+	var totalCount int
+	for _, group := range rows {
+		hours := template.Round(group.hours)
+		pay := math.Round(group.pay*100) / 100
+		totalHours += hours
+		totalAmount += pay
+		totalCount += group.count
+
+		date, _ := time.Parse("2006-01-02", group.date)
+		values := map[string]interface{}{
+			"date":         date.Format(template.DateFormat),
+			"weekday":      date.Weekday().String(),
+			"category":     strings.Join(group.categories, ", "),
+			"hours":        hoursCell(hours),
+			"count":        group.count,
+			"rate":         rateOf(group.pay, group.hours),
+			"amount":       pay,
+			"customers":    strings.Join(group.customers, ", "),
+			"notes":        strings.Join(group.notes, "; "),
+			"tail_numbers": strings.Join(group.tails, ", "),
+		}
+		sheet.Rows = append(sheet.Rows, template.cells(values))
+	}
+
+	for _, adjustment := range adjustments {
+		amount := math.Round(adjustment.Amount*100) / 100
+		if amount == 0 {
+			continue
+		}
+		totalAmount += amount
+		values := map[string]interface{}{
+			"category": adjustment.Name,
+			"amount":   amount,
+		}
+		if adjustment.Hours != 0 {
+			values["hours"] = math.Round(adjustment.Hours*100) / 100
+		}
+		if adjustment.Count != 0 {
+			values["count"] = adjustment.Count
+		}
+		sheet.Rows = append(sheet.Rows, template.cells(values))
+	}
+
+	if template.Totals {
+		totals := template.cells(map[string]interface{}{
+			"hours":  totalHours,
+			"count":  totalCount,
+			"amount": math.Round(totalAmount*100) / 100,
+		})
+		for i, column := range template.Columns {
+			if !numericFields[column.Field] {
+				totals[i] = "Total"
+				break
+			}
+		}
+		sheet.Rows = append(sheet.Rows, totals)
+	}
+	return sheet
+}
+
+// hoursCell leaves hours blank for rows paid a flat amount.
+func hoursCell(hours float64) interface{} {
+	if hours == 0 {
+		return ""
+	}
+	return hours
+}
+
+func rateOf(pay, hours float64) interface{} {
+	if hours == 0 {
+		return ""
+	}
+	return math.Round(pay/hours*100) / 100
+}
+
+func (template *Template) categoryName(category string) string {
+	if name, ok := template.Categories[category]; ok {
+		return name
+	}
+	return category
+}
+
+func (template *Template) cells(values map[string]interface{}) []interface{} {
+	cells := make([]interface{}, len(template.Columns))
+	for i, column := range template.Columns {
+		value, ok := values[column.Field]
+		if !ok {
+			value = ""
+		}
+		cells[i] = value
+	}
+	return cells
+}
+
+// Text formats a cell for CSV output.
+func (template *Template) Text(cell interface{}) string {
+	switch value := cell.(type) {
+	case float64:
+		return strconv.FormatFloat(value, 'f', template.Decimals, 64)
+	case int:
+		return strconv.Itoa(value)
+	case string:
+		return value
+	}
+	return fmt.Sprint(cell)
+}
+
+// WriteCSV writes the sheet as CSV.
+func (template *Template) WriteCSV(w io.Writer, sheet Sheet) error {
+	writer := csv.NewWriter(w)
+	writer.Write(sheet.Header)
+	for _, cells := range sheet.Rows {
+		record := make([]string, len(cells))
+		for i, cell := range cells {
+			record[i] = template.Text(cell)
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,235 @@
+package timesheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		increment float64
+		mode      string
+		hours     float64
+		want      float64
+	}{
+		{6, "nearest", 1.27, 1.3},
+		{6, "nearest", 0.25, 0.3},
+		{6, "up", 1.21, 1.3},
+		{6, "up", 0.3, 0.3}, // exact multiple, not pushed up by float error
+		{6, "down", 1.29, 1.2},
+		{6, "down", 0.3, 0.3},
+		{15, "nearest", 1.1, 1},
+		{15, "up", 1.01, 1.25},
+		{0, "nearest", 1.234, 1.234},
+		{6, "up", 0, 0},
+	}
+	for _, tt := range tests {
+		template := &Template{Rounding: Rounding{Increment: tt.increment, Mode: tt.mode}}
+		if got := template.Round(tt.hours); got != tt.want {
+			t.Errorf("Round(%v) by %v min %s = %v, want %v", tt.hours, tt.increment, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	// out of order on purpose; Build lists oldest first, then by category
+	lines := []Line{
+		{EntryID: 1, Date: "2025-03-04", Category: "flight", Hours: 1.27, Pay: 63.5, Customer: "Ann"},
+		{EntryID: 2, Date: "2025-03-04", Category: "ground", Hours: 0.5, Pay: 25, Customer: "Bob"},
+		{EntryID: 2, Date: "2025-03-04", Category: "flight", Hours: 0.8, Pay: 40, Customer: "Bob"},
+		{EntryID: 4, Date: "2025-03-03", Category: "rides", Count: 2, Pay: 30},
+		{EntryID: 3, Date: "2025-03-03", Category: "admin", Hours: 1, Pay: 20},
+	}
+	adjustments := []Adjustment{
+		{Name: "overtime", Hours: 0.5, Amount: 12.5},
+		{Name: "checkride", Count: 1, Amount: 0}, // nothing earned, left off
+	}
+	overtime := []string{"", "overtime", "0.50", "", "", "12.50", ""}
+	total := []string{"Total", "", "3.60", "2", "", "191.00", ""}
+
+	tests := []struct {
+		groupBy string
+		want    [][]string
+	}{
+		{"entry", [][]string{
+			{"2025-03-03", "admin", "1.00", "0", "20.00", "20.00", ""},
+			{"2025-03-03", "rides", "", "2", "", "30.00", ""},
+			{"2025-03-04", "Flight", "1.30", "0", "50.00", "63.50", "Ann"},
+			{"2025-03-04", "Flight", "0.80", "0", "50.00", "40.00", "Bob"},
+			{"2025-03-04", "ground", "0.50", "0", "50.00", "25.00", "Bob"},
+			overtime, total,
+		}},
+		// rates are what was paid on the exact 2.07 h, not amount / 2.1
+		{"day_category", [][]string{
+			{"2025-03-03", "admin", "1.00", "0", "20.00", "20.00", ""},
+			{"2025-03-03", "rides", "", "2", "", "30.00", ""},
+			{"2025-03-04", "Flight", "2.10", "0", "50.00", "103.50", "Ann, Bob"},
+			{"2025-03-04", "ground", "0.50", "0", "50.00", "25.00", "Bob"},
+			overtime, total,
+		}},
+		{"day", [][]string{
+			{"2025-03-03", "admin, rides", "1.00", "2", "50.00", "50.00", ""},
+			{"2025-03-04", "Flight, ground", "2.60", "0", "50.00", "128.50", "Ann, Bob"},
+			overtime, total,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.groupBy, func(t *testing.T) {
+			template := &Template{
+				GroupBy:    tt.groupBy,
+				Rounding:   Rounding{Increment: 6, Mode: "nearest"},
+				Categories: map[string]string{"flight": "Flight"},
+				Columns: []Column{
+					{Header: "Date", Field: "date"},
+					{Header: "Code", Field: "category"},
+					{Header: "Hours", Field: "hours"},
+					{Header: "Count", Field: "count"},
+					{Header: "Rate", Field: "rate"},
+					{Header: "Amount", Field: "amount"},
+					{Header: "Students", Field: "customers"},
+				},
+				Totals: true,
+			}
+			template.setDefaults()
+
+			sheet := template.Build(append([]Line(nil), lines...), adjustments)
+			var got [][]string
+			for _, cells := range sheet.Rows {
+				record := make([]string, len(cells))
+				for i, cell := range cells {
+					record[i] = template.Text(cell)
+				}
+				got = append(got, record)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rows =\n%v\nwant\n%v", got, tt.want)
+			}
+			if want := []string{"Date", "Code", "Hours", "Count", "Rate", "Amount", "Students"}; !reflect.DeepEqual(sheet.Header, want) {
+				t.Errorf("header = %v, want %v", sheet.Header, want)
+			}
+		})
+	}
+}
+
+func TestBuildWithoutTotals(t *testing.T) {
+	template := Default()
+	template.Totals = false
+	sheet := template.Build([]Line{{Date: "2025-03-03", Category: "flight", Hours: 1, Pay: 50}}, nil)
+	if len(sheet.Rows) != 1 {
+		t.Errorf("rows = %v, want just the entry row", sheet.Rows)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{"minutes", "rounding: {increment: 15, mode: up}\ncolumns: [{header: Hours, field: hours}]", ""},
+		{"hours are not minutes", "rounding: {increment: 0.1}\ncolumns: [{header: Hours, field: hours}]", "in minutes"},
+		{"negative increment", "rounding: {increment: -6}\ncolumns: [{header: Hours, field: hours}]", "in minutes"},
+		{"unknown mode", "rounding: {increment: 6, mode: sideways}\ncolumns: [{header: Hours, field: hours}]", "rounding mode"},
+		{"unknown grouping", "group_by: week\ncolumns: [{header: Hours, field: hours}]", "group_by"},
+		{"unknown field", "columns: [{header: Plane, field: aircraft}]", "unknown field"},
+		{"no columns", "totals: true", "no columns"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "timesheet.yaml")
+			if err := os.WriteFile(path, []byte(tt.yaml), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			template, err := Load(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Load() = %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load(): %v", err)
+			}
+			if template.Rounding.Increment != 15 || template.GroupBy != "day_category" {
+				t.Errorf("loaded %+v", template)
+			}
+		})
+	}
+}
+
+func TestWriteXLSX(t *testing.T) {
+	template := Default()
+	sheet := Sheet{
+		Name:   "Pay/Log: March",
+		Header: []string{"Date", "Hours", "Notes"},
+		Rows: [][]interface{}{
+			{"2025-03-03", 1.5, "stalls & steep turns"},
+			{"Total", 2, ""},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := template.WriteXLSX(&buf, sheet); err != nil {
+		t.Fatal(err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := map[string]string{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[file.Name] = string(body)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/_rels/workbook.xml.rels", "xl/styles.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("workbook is missing %s", name)
+		}
+	}
+	if !strings.Contains(parts["xl/workbook.xml"], `<sheet name="Pay-Log- March"`) {
+		t.Errorf("sheet name not made safe for Excel: %s", parts["xl/workbook.xml"])
+	}
+	worksheet := parts["xl/worksheets/sheet1.xml"]
+	for _, want := range []string{
+		`<c r="A1" t="inlineStr" s="1"><is><t xml:space="preserve">Date</t></is></c>`,
+		`<c r="B2"><v>1.5</v></c>`,
+		`<t xml:space="preserve">stalls &amp; steep turns</t>`,
+		`<c r="B3"><v>2</v></c>`,
+	} {
+		if !strings.Contains(worksheet, want) {
+			t.Errorf("worksheet is missing %s", want)
+		}
+	}
+	if strings.Contains(worksheet, `r="C3"`) {
+		t.Error("blank cell written")
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	tests := []struct {
+		index int
+		want  string
+	}{
+		{0, "A"}, {25, "Z"}, {26, "AA"}, {27, "AB"}, {701, "ZZ"}, {702, "AAA"},
+	}
+	for _, tt := range tests {
+		if got := columnName(tt.index); got != tt.want {
+			t.Errorf("columnName(%d) = %q, want %q", tt.index, got, tt.want)
+		}
+	}
+}
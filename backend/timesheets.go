@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	db "github.com/theHousedev/pay-log/backend/database"
+	"github.com/theHousedev/pay-log/backend/timesheet"
+)
+
+// timesheetTemplate lays out /api/timesheet. It is replaced by the file
+// named in cfg.yaml when one is configured.
+var timesheetTemplate = timesheet.Default()
+
+// timesheetLines turns the engine's per-entry pay into timesheet lines, so
+// the rows plus overtime and premiums add up to the range's total gross.
+func timesheetLines(priced []db.EntryPayLine) []timesheet.Line {
+	lines := make([]timesheet.Line, 0, len(priced))
+	for _, line := range priced {
+		lines = append(lines, timesheet.Line{
+			EntryID:    line.Entry.ID,
+			Date:       entryDay(line.Entry),
+			Category:   line.Category,
+			Hours:      line.Hours,
+			Count:      line.Count,
+			Pay:        line.Pay,
+			Customer:   deref(line.Entry.Customer),
+			Notes:      deref(line.Entry.Notes),
+			TailNumber: deref(line.Entry.TailNumber),
+		})
+	}
+	return lines
+}
+
+// setupTimesheet downloads a timesheet laid out by the configured template
+// for a pay period (?period_id=) or any view/date/from/to range, as CSV or
+// with ?format=xlsx as an Excel workbook. Overtime and premiums follow the
+// entry rows.
+func setupTimesheet(database *db.Database) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Invalid method", http.StatusMethodNotAllowed)
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = "csv"
+		}
+		if format != "csv" && format != "xlsx" {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: "Invalid format. Use: csv or xlsx",
+			})
+			return
+		}
+
+		var beginDate, endDate string
+		if raw := r.URL.Query().Get("period_id"); raw != "" {
+			periodID, err := strconv.Atoi(raw)
+			if err != nil {
+				toJSON(w, db.Response{
+					Status:  "ERROR",
+					Message: "Invalid pay period ID",
+				})
+				return
+			}
+			period, err := database.GetPayPeriod(periodID)
+			if err != nil {
+				toJSON(w, db.Response{
+					Status:  "ERROR",
+					Message: fmt.Sprintf("Failed to get pay period: %v", err),
+				})
+				return
+			}
+			beginDate, endDate = period.BeginDate, period.EndDate
+		} else {
+			var err error
+			_, beginDate, endDate, err = requestRange(database, r)
+			if err != nil {
+				toJSON(w, db.Response{
+					Status:  "ERROR",
+					Message: err.Error(),
+				})
+				return
+			}
+		}
+
+		totals, priced, err := database.CalculateRangePay(beginDate, endDate)
+		if err != nil {
+			toJSON(w, db.Response{
+				Status:  "ERROR",
+				Message: fmt.Sprintf("Failed to calculate totals: %v", err),
+			})
+			return
+		}
+		// "all" ranges come back resolved to the first and last entry
+		beginDate, endDate = totals["start_date"].(string), totals["end_date"].(string)
+		lines := timesheetLines(priced)
+
+		adjustments := []timesheet.Adjustment{{
+			Name:   "overtime",
+			Hours:  totals["overtime_hours"].(float64),
+			Amount: totals["overtime_pay"].(float64),
+		}}
+		for _, premium := range totals["premiums"].([]db.PremiumLine) {
+			adjustments = append(adjustments, timesheet.Adjustment{
+				Name:   premium.Name,
+				Count:  premium.Entries,
+				Amount: premium.Amount,
+			})
+		}
+
+		sheet := timesheetTemplate.Build(lines, adjustments)
+		filename := fmt.Sprintf("timesheet-%s-%s.%s", beginDate, endDate, format)
+		if format == "xlsx" {
+			attachment(w, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", filename)
+			timesheetTemplate.WriteXLSX(w, sheet)
+			return
+		}
+		attachment(w, "text/csv", filename)
+		timesheetTemplate.WriteCSV(w, sheet)
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	db "github.com/theHousedev/pay-log/backend/database"
+)
+
+const validViews = "period, day, week, month, quarter, year, range, or all"
+
+type WeekSettings struct {
+	Start  time.Weekday
+	ToDate bool
+}
+
+// weekSettings holds the site-wide week defaults from cfg.yaml; requests may
+// override them with week_start and week_to_date.
+var weekSettings = WeekSettings{Start: time.Monday}
+
+func parseWeekday(name string) (time.Weekday, error) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(name, day.String()) || strings.EqualFold(name, day.String()[:3]) {
+			return day, nil
+		}
+	}
+	return time.Sunday, fmt.Errorf("Invalid week start '%s'", name)
+}
+
+// weekSettingsFor applies any week overrides on the request to the defaults.
+func weekSettingsFor(r *http.Request) (WeekSettings, error) {
+	week := weekSettings
+	if start := r.URL.Query().Get("week_start"); start != "" {
+		day, err := parseWeekday(start)
+		if err != nil {
+			return WeekSettings{}, err
+		}
+		week.Start = day
+	}
+	if toDate := r.URL.Query().Get("week_to_date"); toDate != "" {
+		value, err := strconv.ParseBool(toDate)
+		if err != nil {
+			return WeekSettings{}, fmt.Errorf("Invalid week_to_date '%s'", toDate)
+		}
+		week.ToDate = value
+	}
+	return week, nil
+}
+
+// resolveViewRange turns a view name and reference date into inclusive
+// begin/end dates. The "range" view takes its bounds from from/to instead;
+// with any other view a from or to narrows the view's own bounds.
+func resolveViewRange(database *db.Database, view, date, from, to string, week WeekSettings) (string, string, error) {
+	beginDate, endDate, err := viewBounds(database, view, date, from, to, week)
+	if err != nil || view == "range" {
+		return beginDate, endDate, err
+	}
+
+	for _, bound := range []string{from, to} {
+		if bound == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", bound); err != nil {
+			return "", "", fmt.Errorf("Invalid date '%s', expected YYYY-MM-DD", bound)
+		}
+	}
+	beginDate, endDate = laterDate(beginDate, from), earlierDate(endDate, to)
+	if beginDate != "all" && endDate != "all" && beginDate > endDate {
+		return "", "", fmt.Errorf("Invalid range: %s is after %s", beginDate, endDate)
+	}
+	return beginDate, endDate, nil
+}
+
+// viewBounds is a view's own bounds, before any from/to narrowing.
+func viewBounds(database *db.Database, view, date, from, to string, week WeekSettings) (string, string, error) {
+	ref, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return "", "", fmt.Errorf("Invalid date '%s', expected YYYY-MM-DD", date)
+	}
+
+	switch view {
+	case "period":
+		period, err := database.GetCurrentPayPeriod(date)
+		if err != nil {
+			return "", "", fmt.Errorf("Failed to get current period: %v", err)
+		}
+		return strings.Split(period.BeginDate, "T")[0], strings.Split(period.EndDate, "T")[0], nil
+
+	case "day":
+		return date, date, nil
+
+	case "week":
+		return formatRange(weekBounds(ref, week.Start, week.ToDate))
+
+	case "month":
+		start := time.Date(ref.Year(), ref.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return formatRange(start, start.AddDate(0, 1, -1))
+
+	case "quarter":
+		firstMonth := time.Month((int(ref.Month())-1)/3*3 + 1)
+		start := time.Date(ref.Year(), firstMonth, 1, 0, 0, 0, 0, time.UTC)
+		return formatRange(start, start.AddDate(0, 3, -1))
+
+	case "year":
+		start := time.Date(ref.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+		return formatRange(start, start.AddDate(1, 0, -1))
+
+	case "range":
+		if from == "" || to == "" {
+			return "", "", fmt.Errorf("Range view requires both from and to")
+		}
+		for _, bound := range []string{from, to} {
+			if _, err := time.Parse("2006-01-02", bound); err != nil {
+				return "", "", fmt.Errorf("Invalid date '%s', expected YYYY-MM-DD", bound)
+			}
+		}
+		if from > to {
+			return "", "", fmt.Errorf("Invalid range: from %s is after to %s", from, to)
+		}
+		return from, to, nil
+
+	case "all":
+		return "all", "all", nil
+	}
+
+	return "", "", fmt.Errorf("Invalid view type. Use: %s", validViews)
+}
+
+func formatRange(start, end time.Time) (string, string, error) {
+	return start.Format("2006-01-02"), end.Format("2006-01-02"), nil
+}
+
+// weekBounds returns the first day of the week containing date, plus either
+// the last day of that week or, when toDate is set, date itself.
+func weekBounds(date time.Time, weekStart time.Weekday, toDate bool) (time.Time, time.Time) {
+	offset := (int(date.Weekday()) - int(weekStart) + 7) % 7
+	startOfWeek := date.AddDate(0, 0, -offset)
+	if toDate {
+		return startOfWeek, date
+	}
+	return startOfWeek, startOfWeek.AddDate(0, 0, 6)
+}
+
+// laterDate narrows bound to date when date is given and later; "all"
+// bounds are open.
+func laterDate(bound, date string) string {
+	if date != "" && (bound == "all" || date > bound) {
+		return date
+	}
+	return bound
+}
+
+// earlierDate narrows bound to date when date is given and earlier.
+func earlierDate(bound, date string) string {
+	if date != "" && (bound == "all" || date < bound) {
+		return date
+	}
+	return bound
+}
+
+// defaultView is "range" when both explicit bounds are given, otherwise
+// "period"; a single from or to then narrows the period.
+func defaultView(r *http.Request) string {
+	if r.URL.Query().Get("from") != "" && r.URL.Query().Get("to") != "" {
+		return "range"
+	}
+	return "period"
+}
+
+// requestRange resolves a request's view/date/from/to parameters into the
+// view name and its inclusive begin/end dates. Every endpoint that takes a
+// view goes through here so entries, totals and reports agree.
+func requestRange(database *db.Database, r *http.Request) (string, string, string, error) {
+	query := r.URL.Query()
+	view := query.Get("view")
+	if view == "" {
+		view = defaultView(r)
+	}
+	date := query.Get("date")
+	if date == "" {
+		date = time.Now().In(time.Local).Format("2006-01-02")
+	}
+	week, err := weekSettingsFor(r)
+	if err != nil {
+		return view, "", "", err
+	}
+	beginDate, endDate, err := resolveViewRange(database, view, date, query.Get("from"), query.Get("to"), week)
+	return view, beginDate, endDate, err
+}
+
+// keepIfOpen replaces an open ("all") bound with resolved.
+func keepIfOpen(bound, resolved string) string {
+	if bound == "all" {
+		return resolved
+	}
+	return bound
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func mustDate(t *testing.T, value string) time.Time {
+	t.Helper()
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		t.Fatalf("bad test date %q: %v", value, err)
+	}
+	return date
+}
+
+func TestWeekBounds(t *testing.T) {
+	tests := []struct {
+		name      string
+		date      string
+		weekStart time.Weekday
+		toDate    bool
+		wantStart string
+		wantEnd   string
+	}{
+		// Monday weeks across the new year: 2024-12-30 is a Monday
+		{"monday start on monday", "2024-12-30", time.Monday, false, "2024-12-30", "2025-01-05"},
+		{"monday start on tuesday", "2024-12-31", time.Monday, false, "2024-12-30", "2025-01-05"},
+		{"monday start on wednesday", "2025-01-01", time.Monday, false, "2024-12-30", "2025-01-05"},
+		{"monday start on thursday", "2025-01-02", time.Monday, false, "2024-12-30", "2025-01-05"},
+		{"monday start on friday", "2025-01-03", time.Monday, false, "2024-12-30", "2025-01-05"},
+		{"monday start on saturday", "2025-01-04", time.Monday, false, "2024-12-30", "2025-01-05"},
+		{"monday start on sunday", "2025-01-05", time.Monday, false, "2024-12-30", "2025-01-05"},
+		{"monday start next monday", "2025-01-06", time.Monday, false, "2025-01-06", "2025-01-12"},
+
+		// Sunday weeks
+		{"sunday start on sunday", "2024-12-29", time.Sunday, false, "2024-12-29", "2025-01-04"},
+		{"sunday start on wednesday", "2025-01-01", time.Sunday, false, "2024-12-29", "2025-01-04"},
+		{"sunday start on saturday", "2025-01-04", time.Sunday, false, "2024-12-29", "2025-01-04"},
+		{"sunday start next sunday", "2025-01-05", time.Sunday, false, "2025-01-05", "2025-01-11"},
+
+		// other configured starts
+		{"saturday start mid-week", "2025-01-01", time.Saturday, false, "2024-12-28", "2025-01-03"},
+		{"wednesday start on tuesday", "2024-12-31", time.Wednesday, false, "2024-12-25", "2024-12-31"},
+		{"wednesday start on wednesday", "2025-01-01", time.Wednesday, false, "2025-01-01", "2025-01-07"},
+
+		// week-to-date ends on the date itself
+		{"to date monday start", "2025-01-02", time.Monday, true, "2024-12-30", "2025-01-02"},
+		{"to date on first day", "2024-12-30", time.Monday, true, "2024-12-30", "2024-12-30"},
+		{"to date sunday start", "2025-01-01", time.Sunday, true, "2024-12-29", "2025-01-01"},
+
+		// other rollovers
+		{"leap day", "2024-02-29", time.Monday, false, "2024-02-26", "2024-03-03"},
+		{"month end", "2025-05-31", time.Monday, false, "2025-05-26", "2025-06-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := weekBounds(mustDate(t, tt.date), tt.weekStart, tt.toDate)
+			gotStart, gotEnd := start.Format("2006-01-02"), end.Format("2006-01-02")
+			if gotStart != tt.wantStart || gotEnd != tt.wantEnd {
+				t.Errorf("weekBounds(%s, %s, %v) = %s..%s, want %s..%s",
+					tt.date, tt.weekStart, tt.toDate, gotStart, gotEnd, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestWeekBoundsEveryStart(t *testing.T) {
+	// every day of every configured week lands in a 7-day week that starts
+	// on the configured day and contains it
+	for weekStart := time.Sunday; weekStart <= time.Saturday; weekStart++ {
+		for offset := 0; offset < 14; offset++ {
+			date := mustDate(t, "2024-12-25").AddDate(0, 0, offset)
+			start, end := weekBounds(date, weekStart, false)
+			if start.Weekday() != weekStart {
+				t.Errorf("%s week of %s starts on %s", weekStart, date.Format("2006-01-02"), start.Weekday())
+			}
+			if date.Before(start) || date.After(end) || end.Sub(start) != 6*24*time.Hour {
+				t.Errorf("%s week of %s is %s..%s", weekStart, date.Format("2006-01-02"),
+					start.Format("2006-01-02"), end.Format("2006-01-02"))
+			}
+		}
+	}
+}
+
+func TestResolveViewRange(t *testing.T) {
+	monday := WeekSettings{Start: time.Monday}
+	sunday := WeekSettings{Start: time.Sunday}
+	mondayToDate := WeekSettings{Start: time.Monday, ToDate: true}
+
+	tests := []struct {
+		name      string
+		view      string
+		date      string
+		from, to  string
+		week      WeekSettings
+		wantStart string
+		wantEnd   string
+		wantErr   bool
+	}{
+		{"day", "day", "2025-01-01", "", "", monday, "2025-01-01", "2025-01-01", false},
+		{"week across new year", "week", "2025-01-01", "", "", monday, "2024-12-30", "2025-01-05", false},
+		{"sunday week across new year", "week", "2025-01-01", "", "", sunday, "2024-12-29", "2025-01-04", false},
+		{"week to date", "week", "2025-01-01", "", "", mondayToDate, "2024-12-30", "2025-01-01", false},
+		{"month", "month", "2025-02-14", "", "", monday, "2025-02-01", "2025-02-28", false},
+		{"leap month", "month", "2024-02-14", "", "", monday, "2024-02-01", "2024-02-29", false},
+		{"december", "month", "2024-12-30", "", "", monday, "2024-12-01", "2024-12-31", false},
+		{"first quarter", "quarter", "2025-01-01", "", "", monday, "2025-01-01", "2025-03-31", false},
+		{"fourth quarter", "quarter", "2024-12-30", "", "", monday, "2024-10-01", "2024-12-31", false},
+		{"year", "year", "2024-12-30", "", "", monday, "2024-01-01", "2024-12-31", false},
+		{"range", "range", "2025-01-01", "2024-12-30", "2025-01-05", monday, "2024-12-30", "2025-01-05", false},
+		{"all", "all", "2025-01-01", "", "", monday, "all", "all", false},
+		{"month narrowed by from", "month", "2025-02-14", "2025-02-10", "", monday, "2025-02-10", "2025-02-28", false},
+		{"month narrowed by to", "month", "2025-02-14", "", "2025-02-20", monday, "2025-02-01", "2025-02-20", false},
+		{"from before the view", "month", "2025-02-14", "2025-01-15", "", monday, "2025-02-01", "2025-02-28", false},
+		{"all narrowed by from", "all", "2025-01-01", "2025-01-10", "", monday, "2025-01-10", "all", false},
+		{"all narrowed by to", "all", "2025-01-01", "", "2025-01-10", monday, "all", "2025-01-10", false},
+		{"from past the view", "month", "2025-02-14", "2025-03-05", "", monday, "", "", true},
+		{"narrowing bad date", "week", "2025-01-01", "2025-1-1", "", monday, "", "", true},
+		{"range missing to", "range", "2025-01-01", "2024-12-30", "", monday, "", "", true},
+		{"range reversed", "range", "2025-01-01", "2025-01-05", "2024-12-30", monday, "", "", true},
+		{"range bad date", "range", "2025-01-01", "2024-12-30", "2025-1-5", monday, "", "", true},
+		{"bad reference date", "week", "01/01/2025", "", "", monday, "", "", true},
+		{"unknown view", "fortnight", "2025-01-01", "", "", monday, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := resolveViewRange(nil, tt.view, tt.date, tt.from, tt.to, tt.week)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("resolveViewRange(%s) = %s..%s, want an error", tt.view, start, end)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveViewRange(%s): %v", tt.view, err)
+			}
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("resolveViewRange(%s, %s) = %s..%s, want %s..%s",
+					tt.view, tt.date, start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestRequestRangeNarrowsEveryView(t *testing.T) {
+	// the same parameters resolve the same way for entries, totals, series
+	// and exports, which all go through requestRange
+	r := httptest.NewRequest("GET", "/api/get-totals?view=week&date=2025-01-01&from=2025-01-01", nil)
+	view, start, end, err := requestRange(nil, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if view != "week" || start != "2025-01-01" || end != "2025-01-05" {
+		t.Errorf("requestRange = %s %s..%s, want week 2025-01-01..2025-01-05", view, start, end)
+	}
+
+	r = httptest.NewRequest("GET", "/api/get-entries?view=week&date=2025-01-01&from=2025-01-01&type=flight", nil)
+	_, filter, err := viewEntryFilter(nil, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filter.BeginDate != start || filter.EndDate != end {
+		t.Errorf("entry filter %s..%s, want the totals range %s..%s", filter.BeginDate, filter.EndDate, start, end)
+	}
+}